@@ -1,8 +1,8 @@
 package goebaykleinanzeigen
 
 import (
+	"bytes"
 	"context"
-	"fmt"
 	"net/http"
 	"time"
 )
@@ -10,7 +10,7 @@ import (
 // AdRepo represents an AdRepo
 // The AdRepo resolves a single ad
 type AdRepo struct {
-	client *http.Client
+	client *Client
 }
 
 // AdItem represents a single AdItem
@@ -27,6 +27,8 @@ type AdItem struct {
 	Details         []*Detail `json:"details"`
 	Extras          []string  `json:"extras"`
 	Seller          *Seller   `json:"seller"`
+	// Raw is only set if the ad was fetched with KeepRaw
+	Raw *RawPage `json:"raw,omitempty"`
 }
 
 // Seller represents the seller of an aditem
@@ -42,48 +44,38 @@ type Detail struct {
 	Value string `json:"value"`
 }
 
-// NewAdRepo creates a new AdRepo, if client is nil, one will be created
+// NewAdRepo creates a new AdRepo, if client is nil, one will be created.
+// The AdRepo gets its own rate limiter, use Client.AdRepo to share one with an AdListRepo
 func NewAdRepo(client *http.Client) *AdRepo {
-	ar := &AdRepo{}
+	return NewClient(WithHTTPClient(client)).AdRepo()
+}
 
-	if client == nil {
-		ar.client = &http.Client{}
-	} else {
-		ar.client = client
-	}
+// Fetch fetches a single AdItem.
+// The url may be relative to the base URL of the Client, links to the old or new domain are moved to the configured host.
+// A deleted ad results in an error matching ErrNotFound or ErrGone, see StatusError
+func (ar *AdRepo) Fetch(ctx context.Context, url string, opts ...FetchOption) (*AdItem, error) {
+	url = ar.client.resolve(url)
 
-	return ar
-}
+	var item *AdItem
+	fo := newFetchOptions(opts)
 
-// Fetch fetches a single AdItem
-func (ar *AdRepo) Fetch(ctx context.Context, url string) (*AdItem, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	page, err := ar.client.load(ctx, AdRequest, url, fo, func(p *page) (int, error) {
+		var err error
+		item, err = ParseAd(bytes.NewReader(p.body), WithParseBaseURL(ar.client.baseURL))
 
-	if err != nil {
-		return nil, err
-	}
+		if item == nil {
+			return 0, err
+		}
 
-	resp, err := ar.client.Do(req)
+		return 1, err
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("unexpected status code or empty content length")
-	}
-
-	// body, _ := io.ReadAll(resp.Body)
-	// fmt.Println(string(body))
-	// r := bytes.NewReader(body)
-	// item, err := parseAdHTML(r)
-
-	item, err := parseAdHTML(resp.Body)
-
-	if err != nil {
-		return nil, err
+	if fo.keepRaw {
+		item.Raw = page.raw()
 	}
 
 	return item, nil
@@ -0,0 +1,159 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Observation is the outcome of a single request as seen by the Client
+type Observation struct {
+	Kind RequestKind
+	// StatusCode is 0 if the request failed without a response
+	StatusCode int
+	Latency    time.Duration
+	// Blocked is set if a block page or captcha was served
+	Blocked bool
+	Err     error
+}
+
+// ObservingLimiter is a Limiter which adapts to the responses it observes.
+// The Client calls Observe after every request if its Limiter implements this interface
+type ObservingLimiter interface {
+	Limiter
+	Observe(obs Observation)
+}
+
+// AdaptiveLimiter is a Limiter which learns the tolerated request rate with AIMD
+// (additive increase, multiplicative decrease): the rate grows slowly while responses are healthy
+// and is cut sharply on 429 or 403 responses, block pages, server errors or latency spikes.
+// It can be used instead of the fixed limiter with WithLimiter.
+// An AdaptiveLimiter is safe for concurrent use by multiple goroutines
+type AdaptiveLimiter struct {
+	mu               sync.Mutex
+	limiter          *rate.Limiter
+	min              rate.Limit
+	max              rate.Limit
+	increase         rate.Limit
+	decrease         float64
+	latencyThreshold time.Duration
+	cutCooldown      time.Duration
+	lastCut          time.Time
+	now              func() time.Time
+}
+
+// AdaptiveLimiterOption configures an AdaptiveLimiter
+type AdaptiveLimiterOption func(*AdaptiveLimiter)
+
+// WithAdaptiveBounds sets the lowest and highest rate, default is 2 and 60 req/minute
+func WithAdaptiveBounds(min, max rate.Limit) AdaptiveLimiterOption {
+	return func(al *AdaptiveLimiter) {
+		al.min = min
+		al.max = max
+	}
+}
+
+// WithAdaptiveIncrease sets the rate added after each healthy response, default is 0.5 req/minute
+func WithAdaptiveIncrease(increase rate.Limit) AdaptiveLimiterOption {
+	return func(al *AdaptiveLimiter) {
+		al.increase = increase
+	}
+}
+
+// WithAdaptiveDecrease sets the factor the rate is multiplied with on a bad response, default is 0.5
+func WithAdaptiveDecrease(factor float64) AdaptiveLimiterOption {
+	return func(al *AdaptiveLimiter) {
+		al.decrease = factor
+	}
+}
+
+// WithAdaptiveLatencyThreshold sets the latency above which a response counts as bad, default is 5 seconds
+func WithAdaptiveLatencyThreshold(threshold time.Duration) AdaptiveLimiterOption {
+	return func(al *AdaptiveLimiter) {
+		al.latencyThreshold = threshold
+	}
+}
+
+// NewAdaptiveLimiter creates a new AdaptiveLimiter starting at the initial rate
+func NewAdaptiveLimiter(initial rate.Limit, opts ...AdaptiveLimiterOption) *AdaptiveLimiter {
+	al := &AdaptiveLimiter{
+		limiter:          rate.NewLimiter(initial, 1),
+		min:              rate.Every(30 * time.Second),
+		max:              rate.Every(time.Second),
+		increase:         rate.Every(2 * time.Minute),
+		decrease:         0.5,
+		latencyThreshold: 5 * time.Second,
+		cutCooldown:      10 * time.Second,
+		now:              time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(al)
+	}
+
+	return al
+}
+
+// Wait blocks until a request may be sent or the context is done
+func (al *AdaptiveLimiter) Wait(ctx context.Context) error {
+	return al.limiter.Wait(ctx)
+}
+
+// Rate returns the currently learned rate
+func (al *AdaptiveLimiter) Rate() rate.Limit {
+	return al.limiter.Limit()
+}
+
+// Observe adapts the rate to the outcome of a request
+func (al *AdaptiveLimiter) Observe(obs Observation) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	current := al.limiter.Limit()
+	now := al.now()
+
+	switch {
+	case obs.Blocked,
+		obs.StatusCode == http.StatusTooManyRequests,
+		obs.StatusCode == http.StatusForbidden,
+		obs.StatusCode >= 500,
+		obs.StatusCode != 0 && al.latencyThreshold > 0 && obs.Latency > al.latencyThreshold:
+		// responses to requests sent before the last cut would cut the rate again
+		if now.Sub(al.lastCut) < al.cutCooldown {
+			return
+		}
+
+		al.lastCut = now
+		next := rate.Limit(float64(current) * al.decrease)
+
+		if next < al.min {
+			next = al.min
+		}
+
+		al.limiter.SetLimit(next)
+	case obs.StatusCode != 0:
+		next := current + al.increase
+
+		if next > al.max {
+			next = al.max
+		}
+
+		al.limiter.SetLimit(next)
+	}
+}
+
+// observe feeds an ObservingLimiter and rotates the header profile on blocks
+func (c *Client) observe(obs Observation) {
+	if ol, ok := c.limiter.(ObservingLimiter); ok {
+		ol.Observe(obs)
+	}
+
+	blocked := obs.Blocked || obs.StatusCode == http.StatusForbidden || obs.StatusCode == http.StatusTooManyRequests
+
+	if c.headers != nil && blocked {
+		c.headers.blocked()
+	}
+}
@@ -0,0 +1,88 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func Test_AdaptiveLimiter(t *testing.T) {
+	now := time.Date(2021, 3, 22, 12, 0, 0, 0, time.UTC)
+
+	al := NewAdaptiveLimiter(
+		1,
+		WithAdaptiveBounds(0.1, 2),
+		WithAdaptiveIncrease(0.25),
+		WithAdaptiveDecrease(0.5),
+		WithAdaptiveLatencyThreshold(time.Second),
+	)
+	al.now = func() time.Time { return now }
+
+	steps := []struct {
+		name string
+		obs  Observation
+		want rate.Limit
+	}{
+		{name: "healthy", obs: Observation{StatusCode: 200}, want: 1.25},
+		{name: "not-found-is-healthy", obs: Observation{StatusCode: 404}, want: 1.5},
+		{name: "transport-error-ignored", obs: Observation{Err: context.DeadlineExceeded}, want: 1.5},
+		{name: "rate-limited", obs: Observation{StatusCode: 429}, want: 0.75},
+		{name: "cut-only-once", obs: Observation{StatusCode: 429}, want: 0.75},
+		{name: "increase-after-cut", obs: Observation{StatusCode: 200}, want: 1},
+		{name: "capped", obs: Observation{StatusCode: 200}, want: 1.25},
+	}
+
+	for _, step := range steps {
+		al.Observe(step.obs)
+
+		if got := al.Rate(); math.Abs(float64(got-step.want)) > 1e-9 {
+			t.Errorf("%s: Rate() = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	for _, obs := range []Observation{
+		{StatusCode: 200, Latency: 2 * time.Second},
+		{Blocked: true},
+		{StatusCode: 503},
+		{StatusCode: 403},
+	} {
+		now = now.Add(time.Minute)
+		al.Observe(obs)
+	}
+
+	if got := al.Rate(); got != 0.1 {
+		t.Errorf("Rate() = %v, want the lower bound %v", got, 0.1)
+	}
+
+	for i := 0; i < 100; i++ {
+		al.Observe(Observation{StatusCode: 200})
+	}
+
+	if got := al.Rate(); got != 2 {
+		t.Errorf("Rate() = %v, want the upper bound %v", got, 2)
+	}
+}
+
+func Test_ClientFeedsAdaptiveLimiter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/interstitial/blocked.html")
+	}))
+	defer srv.Close()
+
+	al := NewAdaptiveLimiter(1, WithAdaptiveBounds(0.1, 1))
+	ar := NewClient(WithLimiter(al), WithBaseURL(srv.URL), WithRetryPolicy(NoRetry)).AdRepo()
+
+	if _, err := ar.Fetch(context.Background(), "/s-anzeige/1"); err == nil {
+		t.Fatal("expected an error for a block page")
+	}
+
+	// the healthy 200 response is capped at 1, the block page cuts it in half
+	if got := al.Rate(); got != 0.5 {
+		t.Errorf("block page did not cut the rate, Rate() = %v, want %v", got, 0.5)
+	}
+}
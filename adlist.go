@@ -1,21 +1,30 @@
 package goebaykleinanzeigen
 
 import (
+	"bytes"
 	"context"
-	"fmt"
 	"net/http"
+	"time"
 )
 
 // AdListRepo represents an AdListRepo
 // The AdListRepo resolves a list of ads
 type AdListRepo struct {
-	client *http.Client
+	client *Client
 }
 
 // AdListResponse represents the response from the Fetch() call
 type AdListResponse struct {
 	Items      []*AdListItem
 	IsLastPage bool
+	// TotalCount is the number of hits of the search, the site only shows the first 50 pages of them
+	TotalCount  int
+	CurrentPage int
+	LastPage    int
+	// PerPage is the number of results per page, it is 0 if there are no hits
+	PerPage int
+	// Raw is only set if the list was fetched with KeepRaw
+	Raw *RawPage
 }
 
 // AdListItem a single item in the returned list
@@ -27,47 +36,69 @@ type AdListItem struct {
 	Location        string `json:"location"`
 	ZipCode         string `json:"zip_code"`
 	Link            string `json:"link"`
+	// Distance is the distance in km to the location of the SearchParam, it is only set if a radius was requested
+	Distance *int `json:"distance,omitempty"`
+	// Thumbnail is the URL of the preview image, it is empty if the ad has no images
+	Thumbnail string `json:"thumbnail"`
+	// ThumbnailRetina is the URL of the preview image in high resolution
+	ThumbnailRetina string `json:"thumbnail_retina"`
+	ImageCount      int    `json:"image_count"`
+	// Snippet is the beginning of the description
+	Snippet string `json:"snippet"`
+	// PostedAt is the time the ad was posted or last bumped in UTC, it is zero if the date is missing
+	PostedAt time.Time `json:"posted_at"`
+	// Tags are the badges of the item like "189.000 km", "EZ 04/2008" or "Versand möglich"
+	Tags []string `json:"tags"`
+	// Mileage is the mileage in km of a vehicle, 0 if unknown
+	Mileage int `json:"mileage"`
+	// RegistrationYear is the year of the first registration of a vehicle, 0 if unknown
+	RegistrationYear int `json:"registration_year"`
+	// RegistrationMonth is the month of the first registration of a vehicle, 0 if unknown
+	RegistrationMonth int `json:"registration_month"`
+	// Shipping reports whether the seller offers shipping
+	Shipping bool `json:"shipping"`
+	// BuyNow reports whether the ad can be bought directly
+	BuyNow bool `json:"buy_now"`
+	// Placement is set for paid top or highlighted items and items of PRO partners
+	Placement Placement `json:"placement"`
+	// InsideRadius is false for alternative results from the surrounding area
+	InsideRadius bool `json:"inside_radius"`
 }
 
-// NewAdListRepo creates a new AdRepo, if client is nil, one will be created
+// NewAdListRepo creates a new AdListRepo, if client is nil, one will be created.
+// The AdListRepo gets its own rate limiter, use Client.AdListRepo to share one with an AdRepo
 func NewAdListRepo(client *http.Client) *AdListRepo {
-	al := &AdListRepo{}
-
-	if client == nil {
-		al.client = &http.Client{}
-	} else {
-		al.client = client
-	}
-
-	return al
+	return NewClient(WithHTTPClient(client)).AdListRepo()
 }
 
-// Fetch fetches a list of ads based on the provided param
-func (al *AdListRepo) Fetch(ctx context.Context, param *SearchParam) (*AdListResponse, error) {
-	url := param.toURL()
+// Fetch fetches a list of ads based on the provided param.
+// Failed requests are returned as *StatusError, unparsable pages as *ParseError
+func (al *AdListRepo) Fetch(ctx context.Context, param *SearchParam, opts ...FetchOption) (*AdListResponse, error) {
+	url := param.toURL(al.client.baseURL)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	var list *AdListResponse
+	fo := newFetchOptions(opts)
 
-	if err != nil {
-		return nil, err
-	}
+	page, err := al.client.load(ctx, ListRequest, url, fo, func(p *page) (int, error) {
+		var err error
+		// relative dates refer to the time the page was fetched, which matters for cached pages
+		list, err = ParseAdList(bytes.NewReader(p.body), WithParseBaseURL(al.client.baseURL), WithReferenceTime(p.fetchedAt))
 
-	resp, err := al.client.Do(req)
+		if list == nil {
+			return 0, err
+		}
+
+		return len(list.Items), err
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	list, err := parseListHTML(resp.Body)
+	list.Items = filterItems(list.Items, fo)
 
-	if err != nil {
-		return nil, err
+	if fo.keepRaw {
+		list.Raw = page.raw()
 	}
 
 	return list, nil
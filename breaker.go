@@ -0,0 +1,235 @@
+package goebaykleinanzeigen
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned while the CircuitBreaker rejects requests, see CircuitOpenError
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerState is the state of a CircuitBreaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets all requests pass
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects all requests until the cool-down is over
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe request pass
+	BreakerHalfOpen
+)
+
+func (bs BreakerState) String() string {
+	switch bs {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+// CircuitOpenError is returned while the CircuitBreaker rejects requests
+type CircuitOpenError struct {
+	// RetryAt is the earliest time a probe request will be let through
+	RetryAt time.Time
+}
+
+func (coe *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%v until %s", ErrCircuitOpen, coe.RetryAt.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrCircuitOpen
+func (coe *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+type breakerOutcome int
+
+const (
+	// outcomeNeutral neither proves nor disproves a block, e.g. a transport error
+	outcomeNeutral breakerOutcome = iota
+	outcomeSuccess
+	outcomeBlocked
+)
+
+// CircuitBreaker pauses all fetching after repeated blocks.
+// It opens after a number of consecutive 403 or 429 responses, block pages or captchas,
+// rejects requests while open and lets a single probe request through after the cool-down.
+// A CircuitBreaker is safe for concurrent use by multiple goroutines
+type CircuitBreaker struct {
+	mu            sync.Mutex
+	state         BreakerState
+	failures      int
+	threshold     int
+	cooldown      time.Duration
+	openedAt      time.Time
+	probing       bool
+	onStateChange func(from, to BreakerState)
+	now           func() time.Time
+}
+
+// CircuitBreakerOption configures a CircuitBreaker
+type CircuitBreakerOption func(*CircuitBreaker)
+
+// OnStateChange sets a callback which is invoked on every state transition
+func OnStateChange(fn func(from, to BreakerState)) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.onStateChange = fn
+	}
+}
+
+// WithCircuitBreaker sets a CircuitBreaker shared by all repos of the Client
+func WithCircuitBreaker(cb *CircuitBreaker) ClientOption {
+	return func(c *Client) {
+		c.breaker = cb
+	}
+}
+
+// NewCircuitBreaker creates a new CircuitBreaker which opens after threshold consecutive blocks
+// and half-opens after cooldown
+func NewCircuitBreaker(threshold int, cooldown time.Duration, opts ...CircuitBreakerOption) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(cb)
+	}
+
+	return cb
+}
+
+// State returns the current state
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.state
+}
+
+// allow returns a *CircuitOpenError if the request has to be rejected
+func (cb *CircuitBreaker) allow() error {
+	cb.mu.Lock()
+
+	from := cb.state
+	retryAt := cb.openedAt.Add(cb.cooldown)
+
+	switch cb.state {
+	case BreakerOpen:
+		if cb.now().Before(retryAt) {
+			cb.mu.Unlock()
+			return &CircuitOpenError{RetryAt: retryAt}
+		}
+
+		cb.state = BreakerHalfOpen
+		cb.probing = true
+	case BreakerHalfOpen:
+		if cb.probing {
+			cb.mu.Unlock()
+			return &CircuitOpenError{RetryAt: cb.now().Add(time.Second)}
+		}
+
+		cb.probing = true
+	}
+
+	to := cb.state
+	cb.mu.Unlock()
+
+	cb.changed(from, to)
+
+	return nil
+}
+
+func (cb *CircuitBreaker) record(outcome breakerOutcome) {
+	cb.mu.Lock()
+
+	from := cb.state
+
+	switch outcome {
+	case outcomeSuccess:
+		cb.failures = 0
+
+		// a late response of a request sent before the breaker opened does not close it
+		if cb.state == BreakerHalfOpen {
+			cb.probing = false
+			cb.state = BreakerClosed
+		}
+	case outcomeBlocked:
+		cb.failures++
+
+		if cb.state == BreakerHalfOpen || (cb.state == BreakerClosed && cb.failures >= cb.threshold) {
+			cb.probing = false
+			cb.state = BreakerOpen
+			cb.openedAt = cb.now()
+			cb.failures = 0
+		}
+	case outcomeNeutral:
+		if cb.state == BreakerHalfOpen {
+			cb.probing = false
+		}
+	}
+
+	to := cb.state
+	cb.mu.Unlock()
+
+	cb.changed(from, to)
+}
+
+func (cb *CircuitBreaker) changed(from, to BreakerState) {
+	if from != to && cb.onStateChange != nil {
+		cb.onStateChange(from, to)
+	}
+}
+
+// breakerOutcomeOf classifies the result of a request or parse
+func breakerOutcomeOf(err error) breakerOutcome {
+	switch {
+	case err == nil:
+		return outcomeSuccess
+	case errors.Is(err, ErrBlocked), errors.Is(err, ErrRateLimited):
+		return outcomeBlocked
+	case errors.Is(err, ErrCircuitOpen):
+		return outcomeNeutral
+	case errors.Is(err, ErrParse):
+		// the page was served, even if its layout changed
+		return outcomeSuccess
+	}
+
+	var se *StatusError
+
+	// every other status code proves that we are not blocked
+	if errors.As(err, &se) {
+		return outcomeSuccess
+	}
+
+	return outcomeNeutral
+}
+
+// reportParse records the outcome of a parsed page, block pages are only detected while parsing
+func (c *Client) reportParse(kind RequestKind, p *page, err error) {
+	if !p.requested {
+		return
+	}
+
+	if c.breaker != nil {
+		c.breaker.record(breakerOutcomeOf(err))
+	}
+
+	var ie *InterstitialError
+	blocked := errors.As(err, &ie) && errors.Is(err, ErrBlocked)
+
+	p.proxy.report(blocked)
+
+	if blocked {
+		c.observe(Observation{Kind: kind, Blocked: true, Err: err})
+	}
+}
@@ -0,0 +1,141 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_CircuitBreaker(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		responses = []string{"403", "403", "captcha", "ad"}
+		requests  int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		response := responses[requests]
+		requests++
+
+		switch response {
+		case "403":
+			w.WriteHeader(http.StatusForbidden)
+		case "captcha":
+			http.ServeFile(w, r, "testdata/interstitial/captcha.html")
+		case "ad":
+			http.ServeFile(w, r, "testdata/aditem/generic-ad.html")
+		}
+	}))
+	defer srv.Close()
+
+	now := time.Date(2021, 3, 22, 12, 0, 0, 0, time.UTC)
+	transitions := []string{}
+
+	cb := NewCircuitBreaker(2, time.Minute, OnStateChange(func(from, to BreakerState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	}))
+	cb.now = func() time.Time { return now }
+
+	ar := NewClient(
+		WithoutRateLimit(),
+		WithRetryPolicy(NoRetry),
+		WithBaseURL(srv.URL),
+		WithCircuitBreaker(cb),
+	).AdRepo()
+
+	fetch := func() error {
+		_, err := ar.Fetch(context.Background(), "/s-anzeige/1")
+		return err
+	}
+
+	_ = fetch()
+	_ = fetch()
+
+	if cb.State() != BreakerOpen {
+		t.Fatalf("State() = %v, want %v", cb.State(), BreakerOpen)
+	}
+
+	var coe *CircuitOpenError
+
+	if err := fetch(); !errors.As(err, &coe) || !coe.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected CircuitOpenError, got %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("open breaker let a request through")
+	}
+
+	now = now.Add(time.Minute)
+
+	if err := fetch(); !errors.Is(err, ErrBlocked) {
+		t.Errorf("expected captcha, got %v", err)
+	}
+
+	if cb.State() != BreakerOpen {
+		t.Errorf("failed probe did not open the breaker again")
+	}
+
+	now = now.Add(time.Minute)
+
+	if err := fetch(); err != nil {
+		t.Errorf("probe failed: %v", err)
+	}
+
+	want := []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}
+
+	if !reflect.DeepEqual(transitions, want) {
+		t.Errorf("transitions = %v, want %v", transitions, want)
+	}
+}
+
+func Test_CircuitBreakerSingleProbe(t *testing.T) {
+	now := time.Date(2021, 3, 22, 12, 0, 0, 0, time.UTC)
+	cb := NewCircuitBreaker(1, time.Minute)
+	cb.now = func() time.Time { return now }
+
+	cb.record(outcomeBlocked)
+	now = now.Add(time.Minute)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if cb.allow() == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if allowed != 1 {
+		t.Errorf("half-open breaker allowed %d probes, want 1", allowed)
+	}
+
+	cb.record(outcomeNeutral)
+
+	if err := cb.allow(); err != nil {
+		t.Errorf("expected a new probe after a neutral outcome, got %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package goebaykleinanzeigen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiskCache stores fetched pages in a directory.
+// Entries are served without a request until their TTL expires,
+// afterwards they are revalidated with the ETag or Last-Modified header if the site sent one.
+// A DiskCache is safe for concurrent use by multiple goroutines
+type DiskCache struct {
+	mu      sync.Mutex
+	dir     string
+	ttl     map[RequestKind]time.Duration
+	maxSize int64
+	// size is the size of the entries in dir, it is -1 until the directory was measured
+	size int64
+	now  func() time.Time
+}
+
+// DiskCacheOption configures a DiskCache
+type DiskCacheOption func(*DiskCache)
+
+type cacheEntry struct {
+	URL          string      `json:"url"`
+	FinalURL     string      `json:"final_url,omitempty"`
+	Header       http.Header `json:"header,omitempty"`
+	StoredAt     time.Time   `json:"stored_at"`
+	ETag         string      `json:"etag"`
+	LastModified string      `json:"last_modified"`
+	Body         []byte      `json:"body"`
+}
+
+// WithCacheTTL sets how long pages of the given kind are served from the cache.
+// A TTL of 0 disables caching for the kind.
+// By default ad pages are cached for 6 hours and list pages are not cached
+func WithCacheTTL(kind RequestKind, ttl time.Duration) DiskCacheOption {
+	return func(dc *DiskCache) {
+		dc.ttl[kind] = ttl
+	}
+}
+
+// WithCacheMaxSize sets the maximum size of the cache directory in bytes,
+// the least recently stored entries are evicted first until 90 percent of the size are used. Default is 256 MiB
+func WithCacheMaxSize(size int64) DiskCacheOption {
+	return func(dc *DiskCache) {
+		dc.maxSize = size
+	}
+}
+
+// WithCache sets a DiskCache for list and ad fetches.
+// Cache hits don't consume a token of the rate limiter
+func WithCache(cache *DiskCache) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// NewDiskCache creates a new DiskCache in dir, the directory is created if it does not exist
+func NewDiskCache(dir string, opts ...DiskCacheOption) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	dc := &DiskCache{
+		dir: dir,
+		ttl: map[RequestKind]time.Duration{
+			AdRequest: 6 * time.Hour,
+		},
+		maxSize: 256 << 20,
+		size:    -1,
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(dc)
+	}
+
+	return dc, nil
+}
+
+func (dc *DiskCache) enabled(kind RequestKind) bool {
+	return dc.ttl[kind] > 0
+}
+
+func (dc *DiskCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dc.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// get returns the entry for url and whether it is still fresh
+func (dc *DiskCache) get(kind RequestKind, url string) (*cacheEntry, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	raw, err := ioutil.ReadFile(dc.path(url))
+
+	if err != nil {
+		return nil, false
+	}
+
+	entry := &cacheEntry{}
+
+	if err := json.Unmarshal(raw, entry); err != nil || entry.URL != url {
+		return nil, false
+	}
+
+	return entry, dc.now().Sub(entry.StoredAt) < dc.ttl[kind]
+}
+
+// put stores the entry and evicts old entries once the cache exceeds maxSize.
+// The size is tracked in memory, the directory is only scanned to measure it once and to evict entries
+func (dc *DiskCache) put(entry *cacheEntry) error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	entry.StoredAt = dc.now()
+
+	raw, err := json.Marshal(entry)
+
+	if err != nil {
+		return err
+	}
+
+	if dc.maxSize > 0 && dc.size < 0 {
+		if err := dc.evict(); err != nil {
+			return err
+		}
+	}
+
+	path := dc.path(entry.URL)
+	var replaced int64
+
+	if info, err := os.Stat(path); err == nil {
+		replaced = info.Size()
+	}
+
+	// write to a unique temporary file first so readers never see a partial entry,
+	// other processes sharing the directory use their own temporary files
+	tmp, err := ioutil.TempFile(dc.dir, "entry-*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if dc.maxSize <= 0 {
+		return nil
+	}
+
+	dc.size += int64(len(raw)) - replaced
+
+	if dc.size <= dc.maxSize {
+		return nil
+	}
+
+	return dc.evict()
+}
+
+// evict measures the directory and removes the oldest entries until the cache fits into 90 percent of maxSize.
+// The headroom prevents a full cache from being scanned on every put
+func (dc *DiskCache) evict() error {
+	infos, err := ioutil.ReadDir(dc.dir)
+
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	entries := make([]os.FileInfo, 0, len(infos))
+
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+			continue
+		}
+
+		size += info.Size()
+		entries = append(entries, info)
+	}
+
+	dc.size = size
+
+	if size <= dc.maxSize {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+
+	target := dc.maxSize / 10 * 9
+
+	for _, info := range entries {
+		if dc.size <= target {
+			break
+		}
+
+		if err := os.Remove(filepath.Join(dc.dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		dc.size -= info.Size()
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_ClientCache(t *testing.T) {
+	html, err := ioutil.ReadFile("testdata/aditem/generic-ad.html")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests, revalidations int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&revalidations, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write(html)
+	}))
+	defer srv.Close()
+
+	now := time.Date(2021, 3, 22, 12, 0, 0, 0, time.UTC)
+
+	cache, err := NewDiskCache(t.TempDir(), WithCacheTTL(AdRequest, time.Hour))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache.now = func() time.Time { return now }
+
+	limiter := &countingLimiter{}
+	ar := NewClient(WithLimiter(limiter), WithBaseURL(srv.URL), WithCache(cache)).AdRepo()
+
+	fetch := func(opts ...FetchOption) {
+		t.Helper()
+
+		item, err := ar.Fetch(context.Background(), "/s-anzeige/1707662827", opts...)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.ID != "1707662827" {
+			t.Errorf("AdItem.ID = %v, want %v", item.ID, "1707662827")
+		}
+	}
+
+	fetch()
+	fetch()
+
+	if requests != 1 || limiter.count != 1 {
+		t.Errorf("cache hit sent a request: requests = %d, limiter = %d", requests, limiter.count)
+	}
+
+	fetch(BypassCache())
+
+	if requests != 2 {
+		t.Errorf("BypassCache() did not send a request")
+	}
+
+	now = now.Add(2 * time.Hour)
+	fetch()
+	fetch()
+
+	if requests != 3 || revalidations != 1 {
+		t.Errorf("expected one revalidation, got requests = %d, revalidations = %d", requests, revalidations)
+	}
+}
+
+func Test_DiskCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewDiskCache(dir, WithCacheMaxSize(1500))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 3, 22, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	for _, url := range []string{"/s-anzeige/1", "/s-anzeige/2", "/s-anzeige/3"} {
+		if err := cache.put(&cacheEntry{URL: url, Body: []byte(strings.Repeat("a", 500))}); err != nil {
+			t.Fatal(err)
+		}
+
+		// make sure the modification times differ
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if entry, _ := cache.get(AdRequest, "/s-anzeige/1"); entry != nil {
+		t.Errorf("oldest entry was not evicted")
+	}
+
+	if entry, fresh := cache.get(AdRequest, "/s-anzeige/3"); entry == nil || !fresh {
+		t.Errorf("newest entry was evicted")
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var size int64
+
+	for _, info := range infos {
+		size += info.Size()
+	}
+
+	if cache.size != size {
+		t.Errorf("tracked size = %v, want %v", cache.size, size)
+	}
+}
+
+func Test_DiskCacheConcurrentPut(t *testing.T) {
+	dir := t.TempDir()
+
+	// two caches on the same directory behave like two processes
+	caches := make([]*DiskCache, 2)
+
+	for i := range caches {
+		cache, err := NewDiskCache(dir)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		caches[i] = cache
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			body := []byte(strings.Repeat(strconv.Itoa(i%10), 1000+i))
+
+			if err := caches[i%2].put(&cacheEntry{URL: "/s-anzeige/1", Body: body}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	entry, _ := caches[0].get(AdRequest, "/s-anzeige/1")
+
+	if entry == nil {
+		t.Fatal("entry is missing or corrupt")
+	}
+
+	if n := len(entry.Body) - 1000; n < 0 || string(entry.Body) != strings.Repeat(strconv.Itoa(n%10), len(entry.Body)) {
+		t.Errorf("entry was mixed from several writes")
+	}
+
+	if tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmps) != 0 {
+		t.Errorf("temporary files were left behind: %v", tmps)
+	}
+}
+
+func Test_ClientCacheScrubsHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret-session-id"})
+		w.Header().Set("X-Kept", "kept")
+		http.ServeFile(w, r, "testdata/aditem/generic-ad.html")
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cache, err := NewDiskCache(dir, WithCacheTTL(AdRequest, time.Hour))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ar := NewClient(WithoutRateLimit(), WithBaseURL(srv.URL), WithCache(cache)).AdRepo()
+
+	if _, err := ar.Fetch(context.Background(), "/s-anzeige/1707662827"); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := ar.Fetch(context.Background(), "/s-anzeige/1707662827", KeepRaw())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !item.Raw.FromCache || item.Raw.Header.Get("X-Kept") != "kept" || item.Raw.Header.Get("Set-Cookie") != "" {
+		t.Errorf("cached header = %v, want X-Kept without Set-Cookie", item.Raw.Header)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		raw, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(string(raw), "secret-session-id") {
+			t.Errorf("cache file %v contains the cookie", file.Name())
+		}
+	}
+}
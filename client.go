@@ -0,0 +1,174 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultRateLimit is the request rate eBay Kleinanzeigen seems to tolerate (about 40 req/minute)
+var DefaultRateLimit = rate.Every(60 * time.Second / 40)
+
+// Limiter throttles outgoing requests
+// *rate.Limiter satisfies this interface
+type Limiter interface {
+	// Wait blocks until a request may be sent or the context is done
+	Wait(ctx context.Context) error
+}
+
+// Client holds the state shared by AdListRepo and AdRepo, like the http.Client and the rate limiter.
+// A Client is safe for concurrent use by multiple goroutines
+type Client struct {
+	httpClient *http.Client
+	limiter    Limiter
+	retry      RetryPolicy
+	baseURL    string
+	cache      *DiskCache
+	hooks      []Hooks
+	breaker    *CircuitBreaker
+	robots     *robots
+	session    *Session
+	headers    *HeaderRotator
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the http.Client used for all requests
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		if client != nil {
+			c.httpClient = client
+		}
+	}
+}
+
+// WithLimiter replaces the default rate limiter
+func WithLimiter(limiter Limiter) ClientOption {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// WithRateLimit replaces the default rate limiter with a token bucket of the given limit and burst
+func WithRateLimit(limit rate.Limit, burst int) ClientOption {
+	return func(c *Client) {
+		c.limiter = rate.NewLimiter(limit, burst)
+	}
+}
+
+// WithoutRateLimit disables rate limiting completely
+func WithoutRateLimit() ClientOption {
+	return func(c *Client) {
+		c.limiter = nil
+	}
+}
+
+// WithBaseURL sets the URL of the marketplace, default is DefaultBaseURL.
+// This may also point to a local mirror or test server
+func WithBaseURL(base string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(base, "/")
+	}
+}
+
+// NewClient creates a new Client.
+// By default requests are limited to DefaultRateLimit
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
+		httpClient: &http.Client{},
+		limiter:    rate.NewLimiter(DefaultRateLimit, 1),
+		retry:      DefaultRetryPolicy,
+		baseURL:    DefaultBaseURL,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// the caller supplied client may be used elsewhere, so the jar is set on a copy
+	if c.session != nil {
+		httpClient := *c.httpClient
+		httpClient.Jar = c.session
+		c.httpClient = &httpClient
+	}
+
+	return c
+}
+
+// AdListRepo creates a new AdListRepo which shares this Client
+func (c *Client) AdListRepo() *AdListRepo {
+	return &AdListRepo{client: c}
+}
+
+// AdRepo creates a new AdRepo which shares this Client
+func (c *Client) AdRepo() *AdRepo {
+	return &AdRepo{client: c}
+}
+
+// BaseURL returns the configured URL of the marketplace
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// resolve resolves ref against the base URL.
+// Absolute links to the old or new marketplace domain are moved to the configured host,
+// this saves the redirect between the domains
+func (c *Client) resolve(ref string) string {
+	base, err := url.Parse(c.baseURL)
+
+	if err != nil {
+		return ref
+	}
+
+	u, err := url.Parse(ref)
+
+	if err != nil {
+		return ref
+	}
+
+	if !u.IsAbs() || (isMarketplaceHost(u.Host) && u.Host != base.Host) {
+		u.Scheme = base.Scheme
+		u.Host = base.Host
+		u.Path = strings.TrimRight(base.Path, "/") + "/" + strings.TrimLeft(u.Path, "/")
+	}
+
+	return u.String()
+}
+
+func isMarketplaceHost(host string) bool {
+	for _, base := range []string{DefaultBaseURL, LegacyBaseURL} {
+		if host == strings.TrimPrefix(base, "https://") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Client) wait(ctx context.Context) error {
+	if c.robots == nil {
+		if c.limiter == nil {
+			return nil
+		}
+
+		return c.limiter.Wait(ctx)
+	}
+
+	// the Scheduler may be shared with other clients, so it only gets the Crawl-delay of this request
+	if _, ok := c.limiter.(*Scheduler); ok {
+		return c.limiter.Wait(contextWithTurnWait(ctx, c.robots.waitCrawlDelay))
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	return c.robots.waitCrawlDelay(ctx)
+}
@@ -0,0 +1,153 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type countingLimiter struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (cl *countingLimiter) Wait(_ context.Context) error {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.count++
+	return nil
+}
+
+func newFixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/s-anzeige/", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/aditem/generic-ad.html")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/adlist/last-page.html")
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func Test_ClientSharedLimiter(t *testing.T) {
+	srv := newFixtureServer(t)
+	limiter := &countingLimiter{}
+	client := NewClient(WithLimiter(limiter), WithBaseURL(srv.URL))
+	ar := client.AdRepo()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := ar.Fetch(context.Background(), "/s-anzeige/1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if _, err := client.AdListRepo().Fetch(context.Background(), &SearchParam{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if limiter.count != 6 {
+		t.Errorf("limiter count = %d, want %d", limiter.count, 6)
+	}
+}
+
+func Test_ClientWithoutRateLimit(t *testing.T) {
+	client := NewClient(WithoutRateLimit())
+
+	if client.limiter != nil {
+		t.Errorf("expected no limiter, got %v", client.limiter)
+	}
+
+	if err := client.wait(context.Background()); err != nil {
+		t.Errorf("did not expect error, got %v", err)
+	}
+}
+
+func Test_ClientResolve(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		ref  string
+		want string
+	}{
+		{
+			name: "relative",
+			base: DefaultBaseURL,
+			ref:  "/s-anzeige/1",
+			want: DefaultBaseURL + "/s-anzeige/1",
+		},
+		{
+			name: "legacy-domain",
+			base: DefaultBaseURL,
+			ref:  LegacyBaseURL + "/s-anzeige/1",
+			want: DefaultBaseURL + "/s-anzeige/1",
+		},
+		{
+			name: "new-domain-on-legacy-base",
+			base: LegacyBaseURL,
+			ref:  DefaultBaseURL + "/s-anzeige/1",
+			want: LegacyBaseURL + "/s-anzeige/1",
+		},
+		{
+			name: "mirror",
+			base: "http://127.0.0.1:8080/mirror/",
+			ref:  DefaultBaseURL + "/s-anzeige/1",
+			want: "http://127.0.0.1:8080/mirror/s-anzeige/1",
+		},
+		{
+			name: "relative-mirror",
+			base: "http://127.0.0.1:8080/mirror",
+			ref:  "s-anzeige/1",
+			want: "http://127.0.0.1:8080/mirror/s-anzeige/1",
+		},
+		{
+			name: "foreign-domain",
+			base: DefaultBaseURL,
+			ref:  "https://example.com/s-anzeige/1",
+			want: "https://example.com/s-anzeige/1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(WithBaseURL(tt.base))
+
+			if got := client.resolve(tt.ref); got != tt.want {
+				t.Errorf("Client.resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ClientBaseURLLinks(t *testing.T) {
+	srv := newFixtureServer(t)
+	client := NewClient(WithoutRateLimit(), WithBaseURL(srv.URL))
+
+	list, err := client.AdListRepo().Fetch(context.Background(), &SearchParam{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range list.Items {
+		if want := srv.URL + "/s-anzeige/" + item.ID; item.Link != want {
+			t.Errorf("AdListItem.Link = %v, want %v", item.Link, want)
+		}
+	}
+}
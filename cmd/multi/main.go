@@ -5,10 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"time"
 
 	goebay "github.com/julez-dev/goebaykleinanzeigen"
-	"golang.org/x/time/rate"
 )
 
 func main() {
@@ -23,10 +21,11 @@ func main() {
 		},
 	}
 
-	rl := rate.NewLimiter(rate.Every(60*time.Second/40), 1) // 40 reads per 60 seconds
-
-	al := goebay.NewAdListRepo(nil)
-	ar := goebay.NewAdRepo(nil)
+	// start at 40 reads per 60 seconds and adapt to the responses of the site
+	limiter := goebay.NewAdaptiveLimiter(goebay.DefaultRateLimit)
+	client := goebay.NewClient(goebay.WithLimiter(limiter))
+	al := client.AdListRepo()
+	ar := client.AdRepo()
 
 	for {
 		adList, err := al.Fetch(context.TODO(), params)
@@ -36,11 +35,6 @@ func main() {
 		}
 
 		for _, ad := range adList.Items {
-			if err := rl.Wait(context.TODO()); err != nil {
-				log.Fatalln(err)
-			}
-
-			// TODO ad timeouts and retries
 			adItem, err := ar.Fetch(context.TODO(), ad.Link)
 
 			if err != nil {
@@ -51,6 +45,8 @@ func main() {
 			fmt.Println(string(adItemDbg))
 		}
 
+		log.Printf("page %d done, learned rate: %.1f req/minute", params.Page, float64(limiter.Rate())*60)
+
 		if adList.IsLastPage {
 			break
 		}
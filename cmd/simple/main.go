@@ -5,10 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"time"
 
 	goebay "github.com/julez-dev/goebaykleinanzeigen"
-	"golang.org/x/time/rate"
 )
 
 func main() {
@@ -23,9 +21,10 @@ func main() {
 		},
 	}
 
-	rl := rate.NewLimiter(rate.Every(60*time.Second/40), 1) // 40 reads per 60 seconds
-	al := goebay.NewAdListRepo(nil)
-	ar := goebay.NewAdRepo(nil)
+	// the client limits list and ad requests to 40 reads per 60 seconds
+	client := goebay.NewClient()
+	al := client.AdListRepo()
+	ar := client.AdRepo()
 
 	resp, err := al.Fetch(context.TODO(), params)
 
@@ -34,11 +33,6 @@ func main() {
 	}
 
 	for _, item := range resp.Items {
-		if err := rl.Wait(context.TODO()); err != nil {
-			log.Fatalln(err)
-		}
-
-		// TODO ad timeouts and retries
 		car, err := ar.Fetch(context.TODO(), item.Link)
 
 		if err != nil {
@@ -0,0 +1,117 @@
+package goebaykleinanzeigen
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrNotFound is returned if the requested page does not exist (404)
+	ErrNotFound = errors.New("not found")
+	// ErrGone is returned if the requested ad was deleted (410)
+	ErrGone = errors.New("gone")
+	// ErrRateLimited is returned if too many requests were sent (429)
+	ErrRateLimited = errors.New("rate limited")
+	// ErrBlocked is returned if access was denied (403)
+	ErrBlocked = errors.New("blocked")
+	// ErrUpstream is returned if eBay Kleinanzeigen failed to answer the request (5xx)
+	ErrUpstream = errors.New("upstream error")
+	// ErrUnexpectedStatus is returned for every other status code which is not 200
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+	// ErrParse is returned if a page could not be parsed, most likely because the layout changed
+	ErrParse = errors.New("parse failure")
+)
+
+// StatusError is returned if a request was answered with a status code other than 200.
+// It wraps one of ErrNotFound, ErrGone, ErrRateLimited, ErrBlocked, ErrUpstream or ErrUnexpectedStatus
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Err        error
+}
+
+func newStatusError(url string, statusCode int) *StatusError {
+	se := &StatusError{
+		URL:        url,
+		StatusCode: statusCode,
+	}
+
+	switch {
+	case statusCode == http.StatusNotFound:
+		se.Err = ErrNotFound
+	case statusCode == http.StatusGone:
+		se.Err = ErrGone
+	case statusCode == http.StatusTooManyRequests:
+		se.Err = ErrRateLimited
+	case statusCode == http.StatusForbidden:
+		se.Err = ErrBlocked
+	case statusCode >= 500 && statusCode <= 599:
+		se.Err = ErrUpstream
+	default:
+		se.Err = ErrUnexpectedStatus
+	}
+
+	return se
+}
+
+func (se *StatusError) Error() string {
+	return fmt.Sprintf("%v: status code %d for %s", se.Err, se.StatusCode, se.URL)
+}
+
+func (se *StatusError) Unwrap() error {
+	return se.Err
+}
+
+// ParseError is returned if a required element could not be parsed.
+// errors.Is(err, ErrParse) reports true for every ParseError
+type ParseError struct {
+	URL string
+	// Selector is the CSS selector of the failing element
+	Selector string
+	Err      error
+}
+
+func (pe *ParseError) Error() string {
+	msg := ErrParse.Error()
+
+	if pe.Selector != "" {
+		msg += " at " + pe.Selector
+	}
+
+	if pe.URL != "" {
+		msg += " for " + pe.URL
+	}
+
+	if pe.Err != nil {
+		msg += ": " + pe.Err.Error()
+	}
+
+	return msg
+}
+
+func (pe *ParseError) Unwrap() error {
+	return pe.Err
+}
+
+// Is reports whether target is ErrParse
+func (pe *ParseError) Is(target error) bool {
+	return target == ErrParse
+}
+
+// withURL attaches the url to a ParseError or InterstitialError, other errors are returned unchanged
+func withURL(err error, url string) error {
+	var pe *ParseError
+
+	if errors.As(err, &pe) && pe.URL == "" {
+		pe.URL = url
+	}
+
+	var ie *InterstitialError
+
+	if errors.As(err, &ie) && ie.URL == "" {
+		ie.URL = url
+	}
+
+	return err
+}
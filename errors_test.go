@@ -0,0 +1,73 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_newStatusError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		want       error
+	}{
+		{name: "not-found", statusCode: 404, want: ErrNotFound},
+		{name: "gone", statusCode: 410, want: ErrGone},
+		{name: "rate-limited", statusCode: 429, want: ErrRateLimited},
+		{name: "blocked", statusCode: 403, want: ErrBlocked},
+		{name: "upstream", statusCode: 502, want: ErrUpstream},
+		{name: "unexpected", statusCode: 301, want: ErrUnexpectedStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := error(newStatusError("https://example.com", tt.statusCode))
+
+			if !errors.Is(err, tt.want) {
+				t.Errorf("newStatusError() = %v, want %v", err, tt.want)
+			}
+
+			var se *StatusError
+
+			if !errors.As(err, &se) || se.StatusCode != tt.statusCode || se.URL != "https://example.com" {
+				t.Errorf("newStatusError() = %#v, want status code %d", se, tt.statusCode)
+			}
+		})
+	}
+}
+
+func Test_FetchErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/deleted") {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+
+		_, _ = w.Write([]byte("<html><body></body></html>"))
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithoutRateLimit(), WithRetryPolicy(NoRetry))
+
+	_, err := client.AdRepo().Fetch(context.Background(), srv.URL+"/deleted")
+
+	if !errors.Is(err, ErrGone) {
+		t.Errorf("Fetch() error = %v, want %v", err, ErrGone)
+	}
+
+	_, err = ParseAdList(strings.NewReader("<html><body></body></html>"))
+
+	var pe *ParseError
+
+	if !errors.As(err, &pe) || !errors.Is(err, ErrParse) {
+		t.Fatalf("ParseAdList() error = %v, want ParseError", err)
+	}
+
+	if pe.Selector != ".pagination-current" {
+		t.Errorf("ParseError.Selector = %v, want %v", pe.Selector, ".pagination-current")
+	}
+}
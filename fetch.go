@@ -0,0 +1,382 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// RequestKind distinguishes list and ad requests
+type RequestKind string
+
+const (
+	ListRequest RequestKind = "list"
+	AdRequest   RequestKind = "ad"
+	// ConsentRequest is the request of the consent step of a Session
+	ConsentRequest RequestKind = "consent"
+)
+
+// FetchOption configures a single Fetch call
+type FetchOption func(*fetchOptions)
+
+type fetchOptions struct {
+	bypassCache bool
+	priority    *Priority
+	keepRaw     bool
+	// excludePromoted and excludeOutsideRadius only apply to lists
+	excludePromoted      bool
+	excludeOutsideRadius bool
+}
+
+// BypassCache skips the cache lookup for this call, the fetched page is still stored in the cache
+func BypassCache() FetchOption {
+	return func(fo *fetchOptions) {
+		fo.bypassCache = true
+	}
+}
+
+func newFetchOptions(opts []FetchOption) *fetchOptions {
+	fo := &fetchOptions{}
+
+	for _, opt := range opts {
+		opt(fo)
+	}
+
+	return fo
+}
+
+// page is a successfully fetched page
+type page struct {
+	url       string
+	body      []byte
+	fromCache bool
+	// requested reports whether a request was sent, this is also true for revalidated cache entries
+	requested bool
+	// entry is stored in the cache once the page was parsed successfully
+	entry *cacheEntry
+	// finalURL is the URL after following redirects
+	finalURL   string
+	statusCode int
+	header     http.Header
+	fetchedAt  time.Time
+	// proxy is the proxy of a ProxyPool which served the page
+	proxy *proxyChoice
+}
+
+// response is a completely read response
+type response struct {
+	url        string
+	statusCode int
+	header     http.Header
+	body       []byte
+	proxy      *proxyChoice
+}
+
+// cachedPage returns the page stored in entry
+func cachedPage(entry *cacheEntry) *page {
+	finalURL := entry.FinalURL
+
+	if finalURL == "" {
+		finalURL = entry.URL
+	}
+
+	return &page{
+		url:        entry.URL,
+		body:       entry.Body,
+		fromCache:  true,
+		finalURL:   finalURL,
+		statusCode: http.StatusOK,
+		header:     entry.Header,
+		fetchedAt:  entry.StoredAt,
+	}
+}
+
+// fetch returns the page for url, either from the cache or by requesting it
+func (c *Client) fetch(ctx context.Context, kind RequestKind, url string, fo *fetchOptions) (*page, error) {
+	if err := c.allowed(ctx, url); err != nil {
+		return nil, err
+	}
+
+	useCache := c.cache != nil && c.cache.enabled(kind)
+	header := http.Header{}
+
+	var cached *cacheEntry
+
+	if useCache && !fo.bypassCache {
+		entry, fresh := c.cache.get(kind, url)
+
+		if fresh {
+			return cachedPage(entry), nil
+		}
+
+		if entry != nil {
+			cached = entry
+
+			if entry.ETag != "" {
+				header.Set("If-None-Match", entry.ETag)
+			}
+
+			if entry.LastModified != "" {
+				header.Set("If-Modified-Since", entry.LastModified)
+			}
+		}
+	}
+
+	if fo.priority != nil {
+		ctx = contextWithPriority(ctx, *fo.priority)
+	}
+
+	resp, err := c.get(ctx, kind, url, header)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.statusCode == http.StatusNotModified && cached != nil {
+		p := cachedPage(cached)
+		p.requested = true
+		p.entry = cached
+		p.fetchedAt = time.Now()
+
+		return p, nil
+	}
+
+	p := &page{
+		url:        url,
+		body:       resp.body,
+		requested:  true,
+		finalURL:   resp.url,
+		statusCode: resp.statusCode,
+		header:     resp.header,
+		fetchedAt:  time.Now(),
+		proxy:      resp.proxy,
+	}
+
+	// cookies and credentials must not end up in the cache files
+	if useCache {
+		p.entry = &cacheEntry{
+			URL:          url,
+			FinalURL:     resp.url,
+			Header:       scrubHeader(resp.header, DefaultScrubHeaders),
+			ETag:         resp.header.Get("ETag"),
+			LastModified: resp.header.Get("Last-Modified"),
+			Body:         resp.body,
+		}
+	}
+
+	return p, nil
+}
+
+// load fetches url and parses the page with parse, which returns the number of parsed items.
+// If a Session is set, a consent page is answered once and the page is fetched again
+func (c *Client) load(ctx context.Context, kind RequestKind, url string, fo *fetchOptions, parse func(p *page) (int, error)) (*page, error) {
+	page, err := c.fetch(ctx, kind, url, fo)
+
+	if err != nil {
+		c.onError(ctx, &ErrorInfo{Kind: kind, URL: url, Err: err})
+		return nil, err
+	}
+
+	err = c.parse(ctx, kind, page, parse)
+
+	var ie *InterstitialError
+
+	if c.session != nil && errors.As(err, &ie) && ie.Kind == ConsentPage {
+		ctx := contextKeepingProfile(ctx)
+		consented, consentErr := c.session.giveConsent(ctx, c.consentClient(), url, page.body)
+
+		if consentErr != nil {
+			err = consentErr
+		}
+
+		if consented {
+			retry := *fo
+			retry.bypassCache = true
+
+			page, err = c.fetch(ctx, kind, url, &retry)
+
+			if err == nil {
+				err = c.parse(ctx, kind, page, parse)
+			}
+		}
+	}
+
+	if err != nil {
+		c.onError(ctx, &ErrorInfo{Kind: kind, URL: url, Err: err})
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// parse parses the page and reports the outcome, successfully parsed pages are stored in the cache
+func (c *Client) parse(ctx context.Context, kind RequestKind, page *page, parse func(p *page) (int, error)) error {
+	start := time.Now()
+	items, err := parse(page)
+
+	info := &ParseInfo{
+		Kind:      kind,
+		URL:       page.url,
+		Items:     items,
+		Duration:  time.Since(start),
+		FromCache: page.fromCache,
+		Err:       withURL(err, page.url),
+	}
+
+	c.parseComplete(ctx, info)
+	c.reportParse(kind, page, err)
+
+	if err != nil {
+		return info.Err
+	}
+
+	// interstitials are not cached, the error is ignored since a failed write only costs a request next time
+	if page.entry != nil {
+		_ = c.cache.put(page.entry)
+	}
+
+	return nil
+}
+
+// get requests the url and retries transient failures according to the RetryPolicy.
+// The returned response always has the status code 200 or 304 for conditional requests,
+// other status codes are returned as *StatusError.
+// The outcome of a returned response has to be reported to the CircuitBreaker after parsing with reportParse
+func (c *Client) get(ctx context.Context, kind RequestKind, url string, header http.Header) (*response, error) {
+	var lastErr error
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.try(ctx, kind, url, header, attempt)
+
+		if err == nil && (resp.statusCode == http.StatusOK || resp.statusCode == http.StatusNotModified) {
+			return resp, nil
+		}
+
+		var delay time.Duration
+		retryable, hasRetryAfter := false, false
+
+		if err != nil {
+			lastErr = err
+			retryable = isRetryableError(ctx, err)
+		} else {
+			lastErr = newStatusError(url, resp.statusCode)
+			retryable = isRetryableStatus(resp.statusCode)
+		}
+
+		// a rejected request did not pass the breaker, so there is nothing to report
+		if c.breaker != nil && !errors.Is(lastErr, ErrCircuitOpen) {
+			c.breaker.record(breakerOutcomeOf(lastErr))
+		}
+
+		if resp != nil {
+			if resp.statusCode == http.StatusTooManyRequests || resp.statusCode == http.StatusServiceUnavailable {
+				delay, hasRetryAfter = parseRetryAfter(resp.header.Get("Retry-After"), time.Now())
+			}
+		}
+
+		if !retryable || attempt >= c.retry.MaxAttempts {
+			return nil, lastErr
+		}
+
+		// waiting longer than the policy allows would block the caller for hours
+		if hasRetryAfter && c.retry.MaxDelay > 0 && delay > c.retry.MaxDelay {
+			return nil, lastErr
+		}
+
+		if !hasRetryAfter {
+			delay = c.retry.backoff(attempt)
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// try sends a single request and reads the whole body
+func (c *Client) try(ctx context.Context, kind RequestKind, url string, header http.Header, attempt int) (*response, error) {
+	ctx, proxy := contextWithProxyChoice(ctx)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	// check the breaker first, a rejected request should not consume a token
+	if c.breaker != nil {
+		if err := c.breaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	waitStart := time.Now()
+
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	profile := c.applyProfile(ctx, req)
+
+	c.beforeRequest(ctx, req, &RequestInfo{
+		Kind:        kind,
+		URL:         url,
+		Attempt:     attempt,
+		LimiterWait: time.Since(waitStart),
+		Profile:     profile,
+	})
+
+	start := time.Now()
+	info := &ResponseInfo{
+		Kind:    kind,
+		URL:     url,
+		Attempt: attempt,
+		Profile: profile,
+	}
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		info.Latency = time.Since(start)
+		info.Err = err
+		c.afterResponse(ctx, info)
+		c.observe(Observation{Kind: kind, Latency: info.Latency, Err: err})
+
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+
+	info.Latency = time.Since(start)
+	info.StatusCode = resp.StatusCode
+	info.BytesRead = int64(len(body))
+	info.Err = err
+	c.afterResponse(ctx, info)
+	c.observe(Observation{Kind: kind, StatusCode: resp.StatusCode, Latency: info.Latency, Err: err})
+
+	if err != nil {
+		return nil, err
+	}
+
+	finalURL := url
+
+	// custom transports may not set the request of the response
+	if resp.Request != nil {
+		finalURL = resp.Request.URL.String()
+	}
+
+	return &response{
+		url:        finalURL,
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       body,
+		proxy:      proxy,
+	}, nil
+}
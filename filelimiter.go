@@ -0,0 +1,117 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// FileLimiter is a token bucket stored in a file, so several processes on the same machine share one budget.
+// Every process creates its own FileLimiter for the same path.
+// The file is guarded by a lock file. The lock is held by the operating system and released if a process crashes,
+// on platforms without file locks a lock left behind by a crashed process is removed after a few seconds.
+// Tokens are reserved before waiting, a process crashing while waiting only loses its own reservation.
+// A FileLimiter is safe for concurrent use by multiple goroutines and processes
+type FileLimiter struct {
+	path      string
+	limit     rate.Limit
+	burst     int
+	staleLock time.Duration
+	now       func() time.Time
+}
+
+type fileBucket struct {
+	Tokens float64   `json:"tokens"`
+	Last   time.Time `json:"last"`
+}
+
+// NewFileLimiter creates a new FileLimiter which stores its state at path
+func NewFileLimiter(path string, limit rate.Limit, burst int) *FileLimiter {
+	return &FileLimiter{
+		path:      path,
+		limit:     limit,
+		burst:     burst,
+		staleLock: 5 * time.Second,
+		now:       time.Now,
+	}
+}
+
+// Wait blocks until a request may be sent or the context is done
+func (fl *FileLimiter) Wait(ctx context.Context) error {
+	delay, err := fl.reserve(ctx, -1)
+
+	if err != nil {
+		return err
+	}
+
+	if err := sleep(ctx, delay); err != nil {
+		// give the reserved token back, the error is ignored since the context is done anyway
+		_, _ = fl.reserve(context.Background(), 1)
+		return err
+	}
+
+	return nil
+}
+
+// reserve adds n tokens to the bucket and returns how long to wait until the bucket is not negative
+func (fl *FileLimiter) reserve(ctx context.Context, n float64) (time.Duration, error) {
+	unlock, err := fl.lock(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer unlock()
+
+	now := fl.now()
+	bucket := fl.read(now)
+
+	elapsed := now.Sub(bucket.Last).Seconds()
+
+	if elapsed > 0 {
+		bucket.Tokens += elapsed * float64(fl.limit)
+	}
+
+	if bucket.Tokens > float64(fl.burst) {
+		bucket.Tokens = float64(fl.burst)
+	}
+
+	bucket.Tokens += n
+	bucket.Last = now
+
+	raw, err := json.Marshal(bucket)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := ioutil.WriteFile(fl.path, raw, 0o644); err != nil {
+		return 0, err
+	}
+
+	if bucket.Tokens >= 0 || fl.limit <= 0 {
+		return 0, nil
+	}
+
+	return time.Duration(-bucket.Tokens / float64(fl.limit) * float64(time.Second)), nil
+}
+
+// read returns the stored bucket, a missing or corrupt file results in a full bucket
+func (fl *FileLimiter) read(now time.Time) *fileBucket {
+	bucket := &fileBucket{}
+	raw, err := ioutil.ReadFile(fl.path)
+
+	if err != nil || json.Unmarshal(raw, bucket) != nil || bucket.Last.After(now) {
+		return &fileBucket{Tokens: float64(fl.burst), Last: now}
+	}
+
+	return bucket
+}
+
+// lock takes the lock file next to the bucket and returns a function to release it
+func (fl *FileLimiter) lock(ctx context.Context) (func(), error) {
+	return lockFile(ctx, fl.path+".lock", fl.staleLock)
+}
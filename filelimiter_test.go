@@ -0,0 +1,119 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_FileLimiterShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.json")
+
+	// two limiters on the same file behave like two processes
+	limiters := []*FileLimiter{
+		NewFileLimiter(path, 20, 1),
+		NewFileLimiter(path, 20, 1),
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(fl *FileLimiter) {
+			defer wg.Done()
+
+			if err := fl.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}(limiters[i%2])
+	}
+
+	wg.Wait()
+
+	// the first token is available right away, the other 9 take 50ms each
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("10 requests at 20 req/s took %v, the budget is not shared", elapsed)
+	}
+}
+
+// a lock file left behind by a crashed process must not block the limiter
+func Test_FileLimiterStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.json")
+	lockPath := path + ".lock"
+
+	if err := ioutil.WriteFile(lockPath, []byte("4711"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-time.Minute)
+
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := NewFileLimiter(path, 1, 1).Wait(ctx); err != nil {
+		t.Errorf("Wait() with a stale lock = %v", err)
+	}
+}
+
+func Test_FileLimiterLockHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.json")
+
+	unlock, err := NewFileLimiter(path, 1, 1).lock(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fl := NewFileLimiter(path, 1, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := fl.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait() while locked = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	unlock()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := fl.Wait(ctx); err != nil {
+		t.Errorf("Wait() after unlock = %v", err)
+	}
+}
+
+func Test_FileLimiterCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.json")
+	fl := NewFileLimiter(path, 1, 1)
+
+	if err := fl.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := fl.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait() = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// the cancelled reservation was returned, so the next token is due in about a second and not two
+	delay, err := fl.reserve(context.Background(), -1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if delay > 1100*time.Millisecond {
+		t.Errorf("reserve() delay = %v, the cancelled token was not returned", delay)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows
+// +build darwin dragonfly freebsd linux netbsd openbsd windows
+
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// lockFile takes an exclusive lock on the file at path. The file stays in place,
+// removing it would let another process lock a new file while the old one is still locked
+func lockFile(ctx context.Context, path string, _ time.Duration) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		locked, err := tryLock(f)
+
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		if locked {
+			return func() {
+				// closing the file releases the lock as well
+				_ = unlock(f)
+				f.Close()
+			}, nil
+		}
+
+		if err := sleep(ctx, 5*time.Millisecond); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+}
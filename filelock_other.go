@@ -0,0 +1,85 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// lockFile creates the lock file exclusively and writes a unique token into it.
+// Without file locks a crashed process can't release its lock, so a lock older than stale is taken over
+func lockFile(ctx context.Context, path string, stale time.Duration) (func(), error) {
+	token, err := lockToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+
+		if err == nil {
+			_, err = f.WriteString(token)
+			f.Close()
+
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+
+			return func() { removeLock(path, token) }, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		// the lock is only held for a read and a write, an old lock belongs to a crashed process
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > stale {
+			if staleToken, err := ioutil.ReadFile(path); err == nil {
+				removeLock(path, string(staleToken))
+			}
+
+			continue
+		}
+
+		if err := sleep(ctx, 5*time.Millisecond); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// removeLock removes the lock file if it still holds token, so a lock taken over by another process stays in place
+func removeLock(path, token string) {
+	moved := path + "." + token
+
+	// renaming is atomic, so only one process gets hold of the lock file
+	if os.Rename(path, moved) != nil {
+		return
+	}
+
+	if raw, err := ioutil.ReadFile(moved); err == nil && string(raw) != token {
+		// the lock changed hands in the meantime, put it back
+		_ = os.Rename(moved, path)
+		return
+	}
+
+	os.Remove(moved)
+}
+
+func lockToken() (string, error) {
+	random := make([]byte, 8)
+
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d-%s", os.Getpid(), hex.EncodeToString(random)), nil
+}
@@ -0,0 +1,187 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// HeaderProfile is a set of headers resembling a browser, it is sent with every request
+type HeaderProfile struct {
+	// Name identifies the profile in hooks and logs
+	Name   string
+	Header http.Header
+}
+
+// DefaultHeaderProfiles are common desktop browsers with a german locale
+var DefaultHeaderProfiles = []*HeaderProfile{
+	{
+		Name: "firefox-windows",
+		Header: http.Header{
+			"User-Agent":                {"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:109.0) Gecko/20100101 Firefox/115.0"},
+			"Accept":                    {"text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8"},
+			"Accept-Language":           {"de-DE,de;q=0.8,en-US;q=0.5,en;q=0.3"},
+			"Upgrade-Insecure-Requests": {"1"},
+		},
+	},
+	{
+		Name: "chrome-windows",
+		Header: http.Header{
+			"User-Agent":                {"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36"},
+			"Accept":                    {"text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8"},
+			"Accept-Language":           {"de-DE,de;q=0.9,en-US;q=0.8,en;q=0.7"},
+			"Upgrade-Insecure-Requests": {"1"},
+		},
+	},
+	{
+		Name: "safari-macos",
+		Header: http.Header{
+			"User-Agent":      {"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.5 Safari/605.1.15"},
+			"Accept":          {"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+			"Accept-Language": {"de-DE,de;q=0.9"},
+		},
+	},
+}
+
+// HeaderRotator hands out one HeaderProfile at a time and switches to the next one according to its policy.
+// By default the profile is only switched by calling Rotate.
+// If the Client has a Session, the Session is rotated together with the profile, so cookies and fingerprint stay consistent.
+// A HeaderRotator is safe for concurrent use by multiple goroutines
+type HeaderRotator struct {
+	mu       sync.Mutex
+	profiles []*HeaderProfile
+	current  int
+	requests int
+	every    int
+	onBlock  bool
+	rotated  bool
+}
+
+// HeaderRotatorOption configures a HeaderRotator
+type HeaderRotatorOption func(*HeaderRotator)
+
+// WithRotateEvery switches the profile after n requests
+func WithRotateEvery(n int) HeaderRotatorOption {
+	return func(hr *HeaderRotator) {
+		hr.every = n
+	}
+}
+
+// WithRotateOnBlock switches the profile after a 403 or 429 response, a block page or a captcha
+func WithRotateOnBlock() HeaderRotatorOption {
+	return func(hr *HeaderRotator) {
+		hr.onBlock = true
+	}
+}
+
+// WithHeaderRotator sets the header profiles sent with every request
+func WithHeaderRotator(rotator *HeaderRotator) ClientOption {
+	return func(c *Client) {
+		c.headers = rotator
+	}
+}
+
+// NewHeaderRotator creates a new HeaderRotator, DefaultHeaderProfiles are used if profiles is empty
+func NewHeaderRotator(profiles []*HeaderProfile, opts ...HeaderRotatorOption) *HeaderRotator {
+	if len(profiles) == 0 {
+		profiles = DefaultHeaderProfiles
+	}
+
+	hr := &HeaderRotator{
+		profiles: profiles,
+	}
+
+	for _, opt := range opts {
+		opt(hr)
+	}
+
+	return hr
+}
+
+// Current returns the profile in use
+func (hr *HeaderRotator) Current() *HeaderProfile {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	return hr.profiles[hr.current]
+}
+
+// Rotate switches to the next profile
+func (hr *HeaderRotator) Rotate() {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	hr.rotate()
+}
+
+func (hr *HeaderRotator) rotate() {
+	hr.current = (hr.current + 1) % len(hr.profiles)
+	hr.requests = 0
+	hr.rotated = true
+}
+
+// next returns the profile for the next request and reports whether the profile changed since the last request
+func (hr *HeaderRotator) next() (*HeaderProfile, bool) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	if hr.every > 0 && hr.requests >= hr.every {
+		hr.rotate()
+	}
+
+	hr.requests++
+	rotated := hr.rotated
+	hr.rotated = false
+
+	return hr.profiles[hr.current], rotated
+}
+
+func (hr *HeaderRotator) blocked() {
+	if !hr.onBlock {
+		return
+	}
+
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	hr.rotate()
+}
+
+type keepProfileKey struct{}
+
+// contextKeepingProfile marks requests which must not switch the profile and the Session,
+// like the consent step and the fetch repeated after it. Rotating there would drop the consent cookie
+func contextKeepingProfile(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keepProfileKey{}, true)
+}
+
+// applyProfile sets the headers of the current profile which are not set yet and returns the profile name.
+// Profile and Session are only rotated between top level fetches, see contextKeepingProfile
+func (c *Client) applyProfile(ctx context.Context, req *http.Request) string {
+	if c.headers == nil {
+		return ""
+	}
+
+	if keep, _ := ctx.Value(keepProfileKey{}).(bool); keep {
+		return c.setProfileHeaders(req, c.headers.Current())
+	}
+
+	profile, rotated := c.headers.next()
+
+	// the error is ignored, a stale cookie file is overwritten with the next save
+	if rotated && c.session != nil {
+		_ = c.session.Rotate()
+	}
+
+	return c.setProfileHeaders(req, profile)
+}
+
+func (c *Client) setProfileHeaders(req *http.Request, profile *HeaderProfile) string {
+	for key, values := range profile.Header {
+		if req.Header.Get(key) == "" {
+			req.Header[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+
+	return profile.Name
+}
@@ -0,0 +1,151 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func Test_ClientHeaderRotation(t *testing.T) {
+	var mu sync.Mutex
+	var agents, cookies []string
+	block := false
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		agents = append(agents, r.Header.Get("User-Agent"))
+		cookies = append(cookies, r.Header.Get("Cookie"))
+
+		if r.Header.Get("Accept-Language") != "de-DE" {
+			t.Errorf("Accept-Language = %v, want %v", r.Header.Get("Accept-Language"), "de-DE")
+		}
+
+		if block {
+			block = false
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", Path: "/"})
+		http.ServeFile(w, r, "testdata/aditem/generic-ad.html")
+	}))
+	defer srv.Close()
+
+	profiles := []*HeaderProfile{
+		{Name: "a", Header: http.Header{"User-Agent": {"agent-a"}, "Accept-Language": {"de-DE"}}},
+		{Name: "b", Header: http.Header{"User-Agent": {"agent-b"}, "Accept-Language": {"de-DE"}}},
+	}
+
+	session, err := NewSession("")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hookProfiles []string
+
+	client := NewClient(
+		WithoutRateLimit(),
+		WithRetryPolicy(NoRetry),
+		WithBaseURL(srv.URL),
+		WithSession(session),
+		WithHeaderRotator(NewHeaderRotator(profiles, WithRotateEvery(2), WithRotateOnBlock())),
+		WithHooks(Hooks{
+			AfterResponse: func(_ context.Context, info *ResponseInfo) {
+				hookProfiles = append(hookProfiles, info.Profile)
+			},
+		}),
+	)
+
+	fetch := func() {
+		t.Helper()
+		_, _ = client.AdRepo().Fetch(context.Background(), "/s-anzeige/1707662827")
+	}
+
+	fetch()
+	fetch()
+	// the third request exceeds the limit of the first profile
+	fetch()
+
+	mu.Lock()
+	block = true
+	mu.Unlock()
+
+	// the fourth request is blocked, so the fifth uses the next profile
+	fetch()
+	fetch()
+
+	want := []string{"agent-a", "agent-a", "agent-b", "agent-b", "agent-a"}
+
+	for i := range want {
+		if i >= len(agents) || agents[i] != want[i] {
+			t.Fatalf("agents = %v, want %v", agents, want)
+		}
+	}
+
+	// the session is rotated together with the profile
+	if cookies[1] == "" || cookies[2] != "" {
+		t.Errorf("cookies = %q, want a cookie on the second and none on the third request", cookies)
+	}
+
+	if hookProfiles[2] != "b" {
+		t.Errorf("ResponseInfo.Profile = %v, want %v", hookProfiles[2], "b")
+	}
+}
+
+func Test_ClientHeaderRotationDuringConsent(t *testing.T) {
+	var consents int32
+	srv := newConsentServer(t, &consents)
+
+	session, err := NewSession("")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profiles := []*HeaderProfile{
+		{Name: "a", Header: http.Header{"User-Agent": {"agent-a"}}},
+		{Name: "b", Header: http.Header{"User-Agent": {"agent-b"}}},
+	}
+
+	var hookProfiles []string
+
+	// every request would be due for a rotation, the consent step must still keep its session
+	client := NewClient(
+		WithoutRateLimit(),
+		WithBaseURL(srv.URL),
+		WithSession(session),
+		WithHeaderRotator(NewHeaderRotator(profiles, WithRotateEvery(1))),
+		WithHooks(Hooks{
+			BeforeRequest: func(_ context.Context, _ *http.Request, info *RequestInfo) {
+				hookProfiles = append(hookProfiles, info.Profile)
+			},
+		}),
+	)
+
+	item, err := client.AdRepo().Fetch(context.Background(), "/s-anzeige/1707662827")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item.ID != "1707662827" {
+		t.Errorf("AdItem.ID = %v, want %v", item.ID, "1707662827")
+	}
+
+	if consents != 1 {
+		t.Errorf("consents = %v, want %v", consents, 1)
+	}
+
+	// page, consent and the repeated page share the profile
+	want := []string{"a", "a", "a"}
+
+	if !reflect.DeepEqual(hookProfiles, want) {
+		t.Errorf("profiles = %v, want %v", hookProfiles, want)
+	}
+}
@@ -0,0 +1,108 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Hooks are invoked by the Client for every list and ad fetch, e.g. for logging or metrics.
+// Every hook is optional. Hooks are called synchronously and have to be safe for concurrent use
+type Hooks struct {
+	// BeforeRequest is called before each attempt is sent, after waiting for the rate limiter.
+	// The request may be modified, e.g. to add headers
+	BeforeRequest func(ctx context.Context, req *http.Request, info *RequestInfo)
+	// AfterResponse is called after each attempt, including failed ones
+	AfterResponse func(ctx context.Context, info *ResponseInfo)
+	// OnParseComplete is called after the page was parsed, successful or not
+	OnParseComplete func(ctx context.Context, info *ParseInfo)
+	// OnError is called once if a Fetch call fails
+	OnError func(ctx context.Context, info *ErrorInfo)
+}
+
+// RequestInfo describes an outgoing request
+type RequestInfo struct {
+	Kind RequestKind
+	URL  string
+	// Attempt starts at 1 and is increased for each retry
+	Attempt int
+	// LimiterWait is the time spent waiting for the rate limiter
+	LimiterWait time.Duration
+	// Profile is the name of the HeaderProfile, it is empty without a HeaderRotator
+	Profile string
+}
+
+// ResponseInfo describes the outcome of a single request
+type ResponseInfo struct {
+	Kind    RequestKind
+	URL     string
+	Attempt int
+	// StatusCode is 0 if the request failed without a response
+	StatusCode int
+	// Latency is the time from sending the request until the body was read
+	Latency   time.Duration
+	BytesRead int64
+	// Err is set if the request failed without a response
+	Err error
+	// Profile is the name of the HeaderProfile, it is empty without a HeaderRotator
+	Profile string
+}
+
+// ParseInfo describes a parsed page
+type ParseInfo struct {
+	Kind RequestKind
+	URL  string
+	// Items is the number of parsed ads
+	Items    int
+	Duration time.Duration
+	// FromCache reports whether the page was served from the cache
+	FromCache bool
+	// Err is set if the page could not be parsed
+	Err error
+}
+
+// ErrorInfo describes a failed Fetch call
+type ErrorInfo struct {
+	Kind RequestKind
+	URL  string
+	Err  error
+}
+
+// WithHooks adds hooks to the Client, hooks are called in the order they were added
+func WithHooks(hooks Hooks) ClientOption {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, hooks)
+	}
+}
+
+func (c *Client) beforeRequest(ctx context.Context, req *http.Request, info *RequestInfo) {
+	for _, h := range c.hooks {
+		if h.BeforeRequest != nil {
+			h.BeforeRequest(ctx, req, info)
+		}
+	}
+}
+
+func (c *Client) afterResponse(ctx context.Context, info *ResponseInfo) {
+	for _, h := range c.hooks {
+		if h.AfterResponse != nil {
+			h.AfterResponse(ctx, info)
+		}
+	}
+}
+
+func (c *Client) parseComplete(ctx context.Context, info *ParseInfo) {
+	for _, h := range c.hooks {
+		if h.OnParseComplete != nil {
+			h.OnParseComplete(ctx, info)
+		}
+	}
+}
+
+func (c *Client) onError(ctx context.Context, info *ErrorInfo) {
+	for _, h := range c.hooks {
+		if h.OnError != nil {
+			h.OnError(ctx, info)
+		}
+	}
+}
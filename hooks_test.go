@@ -0,0 +1,97 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_ClientHooks(t *testing.T) {
+	var gotHeader string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Team")
+
+		if r.URL.Path == "/s-anzeige/deleted" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(listPageHTML(1, 1, "1", "2", "3")))
+	}))
+	defer srv.Close()
+
+	var (
+		mu        sync.Mutex
+		requests  []*RequestInfo
+		responses []*ResponseInfo
+		parsed    []*ParseInfo
+		failures  []*ErrorInfo
+	)
+
+	client := NewClient(
+		WithoutRateLimit(),
+		WithBaseURL(srv.URL),
+		WithHooks(Hooks{
+			BeforeRequest: func(_ context.Context, req *http.Request, info *RequestInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+				req.Header.Set("X-Team", "watcher")
+				requests = append(requests, info)
+			},
+			AfterResponse: func(_ context.Context, info *ResponseInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+				responses = append(responses, info)
+			},
+			OnParseComplete: func(_ context.Context, info *ParseInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+				parsed = append(parsed, info)
+			},
+			OnError: func(_ context.Context, info *ErrorInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+				failures = append(failures, info)
+			},
+		}),
+	)
+
+	if _, err := client.AdListRepo().Fetch(context.Background(), &SearchParam{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if gotHeader != "watcher" {
+		t.Errorf("header added by hook = %q, want %q", gotHeader, "watcher")
+	}
+
+	if len(requests) != 1 || requests[0].Kind != ListRequest || requests[0].Attempt != 1 {
+		t.Errorf("unexpected BeforeRequest calls: %+v", requests)
+	}
+
+	if len(responses) != 1 || responses[0].StatusCode != 200 || responses[0].BytesRead == 0 || responses[0].Latency <= 0 {
+		t.Errorf("unexpected AfterResponse calls: %+v", responses)
+	}
+
+	if len(parsed) != 1 || parsed[0].Items != 3 || parsed[0].Err != nil {
+		t.Errorf("unexpected OnParseComplete calls: %+v", parsed)
+	}
+
+	_, err := client.AdRepo().Fetch(context.Background(), "/s-anzeige/deleted")
+
+	if len(failures) != 1 || !errors.Is(failures[0].Err, ErrNotFound) || failures[0].Kind != AdRequest {
+		t.Errorf("unexpected OnError calls: %+v", failures)
+	}
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Fetch() error = %v, want %v", err, ErrNotFound)
+	}
+
+	if last := responses[len(responses)-1]; last.StatusCode != 404 || last.Latency > time.Minute {
+		t.Errorf("unexpected AfterResponse for failed request: %+v", last)
+	}
+}
@@ -0,0 +1,154 @@
+package goebaykleinanzeigen
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PageKind classifies a page which was served instead of the requested content
+type PageKind string
+
+const (
+	// ContentPage is a regular page with the requested content
+	ContentPage PageKind = ""
+	// BlockedPage is served if our requests are denied, e.g. because too many were sent
+	BlockedPage PageKind = "blocked"
+	// CaptchaPage asks to solve a captcha before continuing
+	CaptchaPage PageKind = "captcha"
+	// ConsentPage asks to accept the cookie policy before continuing
+	ConsentPage PageKind = "consent"
+	// MaintenancePage is served while eBay Kleinanzeigen is unavailable
+	MaintenancePage PageKind = "maintenance"
+)
+
+// ErrInterstitial is returned if a page was served instead of the requested content, see InterstitialError
+var ErrInterstitial = errors.New("interstitial page")
+
+// InterstitialError is returned if a blocked, captcha, consent or maintenance page was served instead of the requested content.
+// Blocked and captcha pages also match ErrBlocked
+type InterstitialError struct {
+	URL  string
+	Kind PageKind
+}
+
+func (ie *InterstitialError) Error() string {
+	msg := ErrInterstitial.Error() + " (" + string(ie.Kind) + ")"
+
+	if ie.URL != "" {
+		msg += " for " + ie.URL
+	}
+
+	return msg
+}
+
+// Is reports whether target is ErrInterstitial or ErrBlocked for blocked and captcha pages
+func (ie *InterstitialError) Is(target error) bool {
+	switch target {
+	case ErrInterstitial:
+		return true
+	case ErrBlocked:
+		return ie.Kind == BlockedPage || ie.Kind == CaptchaPage
+	}
+
+	return false
+}
+
+var (
+	captchaSelectors = []string{
+		".g-recaptcha",
+		".h-captcha",
+		"[data-sitekey]",
+		"iframe[src*='captcha']",
+		"form[action*='captcha']",
+		"#captcha",
+	}
+
+	blockedPhrases = []string{
+		"too many requests",
+		"access denied",
+		"zugriff verweigert",
+		"zu viele anfragen",
+		"ungewöhnlich viele anfragen",
+		"ihre anfrage wurde blockiert",
+	}
+
+	maintenancePhrases = []string{
+		"wartungsarbeiten",
+		"wir sind gleich wieder da",
+		"maintenance",
+	}
+
+	consentSelectors = []string{
+		"#gdpr-banner",
+		"#consentBanner",
+		"[id^='sp_message_container']",
+	}
+
+	// contentSelectors mark regular list and ad pages
+	contentSelectors = []string{
+		"#viewad-title",
+		".aditem",
+		".pagination-current",
+		"#srchrslt-adtable",
+		// a search without hits has neither results nor pagination
+		".breadcrump-summary",
+		".splitheader--title",
+	}
+)
+
+// classifyPage detects interstitial pages.
+// The cookie banner is part of every regular page, so consent pages are only reported if the content is missing
+func classifyPage(doc *goquery.Document) PageKind {
+	if hasContent(doc) {
+		return ContentPage
+	}
+
+	for _, sel := range captchaSelectors {
+		if doc.Find(sel).Length() > 0 {
+			return CaptchaPage
+		}
+	}
+
+	text := strings.ToLower(doc.Find("title").Text() + " " + doc.Find("body").Text())
+
+	for _, phrase := range blockedPhrases {
+		if strings.Contains(text, phrase) {
+			return BlockedPage
+		}
+	}
+
+	for _, phrase := range maintenancePhrases {
+		if strings.Contains(text, phrase) {
+			return MaintenancePage
+		}
+	}
+
+	for _, sel := range consentSelectors {
+		if doc.Find(sel).Length() > 0 {
+			return ConsentPage
+		}
+	}
+
+	return ContentPage
+}
+
+func hasContent(doc *goquery.Document) bool {
+	for _, sel := range contentSelectors {
+		if doc.Find(sel).Length() > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkInterstitial returns an *InterstitialError if doc is not a regular page
+func checkInterstitial(doc *goquery.Document) error {
+	if kind := classifyPage(doc); kind != ContentPage {
+		return &InterstitialError{Kind: kind}
+	}
+
+	return nil
+}
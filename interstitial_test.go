@@ -0,0 +1,74 @@
+package goebaykleinanzeigen
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func Test_interstitialPages(t *testing.T) {
+	files, err := filepath.Glob("testdata/interstitial/*.html")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, htmlFilePath := range files {
+		htmlFilename := filepath.Base(htmlFilePath)
+		kind := PageKind(strings.TrimSuffix(htmlFilename, ".html"))
+
+		t.Run(htmlFilename, func(t *testing.T) {
+			html, err := ioutil.ReadFile(htmlFilePath)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, listErr := ParseAdList(bytes.NewReader(html))
+			_, adErr := ParseAd(bytes.NewReader(html))
+
+			for _, err := range []error{listErr, adErr} {
+				var ie *InterstitialError
+
+				if !errors.As(err, &ie) {
+					t.Fatalf("expected InterstitialError, got %v", err)
+				}
+
+				if ie.Kind != kind {
+					t.Errorf("InterstitialError.Kind = %v, want %v", ie.Kind, kind)
+				}
+
+				blocked := kind == BlockedPage || kind == CaptchaPage
+
+				if errors.Is(err, ErrBlocked) != blocked {
+					t.Errorf("errors.Is(err, ErrBlocked) = %v, want %v", !blocked, blocked)
+				}
+			}
+		})
+	}
+}
+
+func Test_classifyPageContent(t *testing.T) {
+	for _, htmlFilePath := range []string{"testdata/adlist/last-page.html", "testdata/aditem/generic-ad.html"} {
+		html, err := ioutil.ReadFile(htmlFilePath)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if kind := classifyPage(doc); kind != ContentPage {
+			t.Errorf("classifyPage(%s) = %v, want content page", htmlFilePath, kind)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+module github.com/julez-dev/goebaykleinanzeigen/metrics
+
+go 1.16
+
+require (
+	github.com/julez-dev/goebaykleinanzeigen v0.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.11.1
+)
@@ -0,0 +1,143 @@
+// Package metrics exposes Prometheus collectors for the goebaykleinanzeigen Client
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	goebay "github.com/julez-dev/goebaykleinanzeigen"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector collects metrics of a goebaykleinanzeigen Client through its hooks.
+// It implements prometheus.Collector and can be registered on any registry
+type Collector struct {
+	requests      *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	limiterWait   *prometheus.HistogramVec
+	retries       *prometheus.CounterVec
+	parseFailures *prometheus.CounterVec
+	interstitials *prometheus.CounterVec
+	items         *prometheus.HistogramVec
+}
+
+// New creates a new Collector, all metrics are prefixed with namespace
+func New(namespace string) *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Number of sent requests by kind and status code, status is \"error\" if no response was received.",
+		}, []string{"kind", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Time from sending a request until its body was read.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"kind"}),
+		limiterWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_wait_seconds",
+			Help:      "Time spent waiting for the rate limiter before a request.",
+			Buckets:   []float64{0.01, 0.1, 0.5, 1, 2, 5, 10, 30, 60, 120},
+		}, []string{"kind"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Number of retried requests.",
+		}, []string{"kind"}),
+		parseFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "parse_failures_total",
+			Help:      "Number of pages which could not be parsed by the failing field.",
+		}, []string{"kind", "field"}),
+		interstitials: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "interstitial_pages_total",
+			Help:      "Number of block, captcha, consent and maintenance pages served instead of content.",
+		}, []string{"kind", "page"}),
+		items: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "items_per_page",
+			Help:      "Number of ads parsed from a page.",
+			Buckets:   []float64{0, 1, 5, 10, 15, 20, 25, 30},
+		}, []string{"kind"}),
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.requests,
+		c.latency,
+		c.limiterWait,
+		c.retries,
+		c.parseFailures,
+		c.interstitials,
+		c.items,
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// Hooks returns the hooks which feed the Collector, pass them to the Client with goebay.WithHooks
+func (c *Collector) Hooks() goebay.Hooks {
+	return goebay.Hooks{
+		BeforeRequest: func(_ context.Context, _ *http.Request, info *goebay.RequestInfo) {
+			kind := string(info.Kind)
+			c.limiterWait.WithLabelValues(kind).Observe(info.LimiterWait.Seconds())
+
+			if info.Attempt > 1 {
+				c.retries.WithLabelValues(kind).Inc()
+			}
+		},
+		AfterResponse: func(_ context.Context, info *goebay.ResponseInfo) {
+			kind := string(info.Kind)
+			status := "error"
+
+			if info.StatusCode != 0 {
+				status = strconv.Itoa(info.StatusCode)
+			}
+
+			c.requests.WithLabelValues(kind, status).Inc()
+			c.latency.WithLabelValues(kind).Observe(info.Latency.Seconds())
+		},
+		OnParseComplete: func(_ context.Context, info *goebay.ParseInfo) {
+			kind := string(info.Kind)
+
+			if info.Err == nil {
+				c.items.WithLabelValues(kind).Observe(float64(info.Items))
+				return
+			}
+
+			var ie *goebay.InterstitialError
+
+			if errors.As(info.Err, &ie) {
+				c.interstitials.WithLabelValues(kind, string(ie.Kind)).Inc()
+				return
+			}
+
+			field := "unknown"
+			var pe *goebay.ParseError
+
+			if errors.As(info.Err, &pe) && pe.Selector != "" {
+				field = pe.Selector
+			}
+
+			c.parseFailures.WithLabelValues(kind, field).Inc()
+		},
+	}
+}
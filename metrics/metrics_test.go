@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	goebay "github.com/julez-dev/goebaykleinanzeigen"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_CollectorHooks(t *testing.T) {
+	c := New("kleinanzeigen")
+	reg := prometheus.NewPedanticRegistry()
+
+	if err := reg.Register(c); err != nil {
+		t.Fatal(err)
+	}
+
+	hooks := c.Hooks()
+	ctx := context.Background()
+	req, _ := http.NewRequest("GET", "https://www.kleinanzeigen.de/s-anzeige/1", nil)
+
+	hooks.BeforeRequest(ctx, req, &goebay.RequestInfo{Kind: goebay.AdRequest, Attempt: 1, LimiterWait: time.Second})
+	hooks.AfterResponse(ctx, &goebay.ResponseInfo{Kind: goebay.AdRequest, StatusCode: 503, Latency: time.Second})
+	hooks.BeforeRequest(ctx, req, &goebay.RequestInfo{Kind: goebay.AdRequest, Attempt: 2})
+	hooks.AfterResponse(ctx, &goebay.ResponseInfo{Kind: goebay.AdRequest, StatusCode: 200, Latency: time.Second})
+	hooks.AfterResponse(ctx, &goebay.ResponseInfo{Kind: goebay.ListRequest, Err: errors.New("connection reset")})
+
+	hooks.OnParseComplete(ctx, &goebay.ParseInfo{Kind: goebay.ListRequest, Items: 25})
+	hooks.OnParseComplete(ctx, &goebay.ParseInfo{Kind: goebay.ListRequest, Err: &goebay.ParseError{Selector: ".pagination-current"}})
+	hooks.OnParseComplete(ctx, &goebay.ParseInfo{Kind: goebay.AdRequest, Err: &goebay.InterstitialError{Kind: goebay.CaptchaPage}})
+
+	tests := []struct {
+		name      string
+		collector prometheus.Collector
+		want      float64
+	}{
+		{name: "requests-503", collector: c.requests.WithLabelValues("ad", "503"), want: 1},
+		{name: "requests-200", collector: c.requests.WithLabelValues("ad", "200"), want: 1},
+		{name: "requests-error", collector: c.requests.WithLabelValues("list", "error"), want: 1},
+		{name: "retries", collector: c.retries.WithLabelValues("ad"), want: 1},
+		{name: "parse-failures", collector: c.parseFailures.WithLabelValues("list", ".pagination-current"), want: 1},
+		{name: "interstitials", collector: c.interstitials.WithLabelValues("ad", "captcha"), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testutil.ToFloat64(tt.collector); got != tt.want {
+				t.Errorf("metric = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	count, err := testutil.GatherAndCount(reg)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3 request series, 2 latency, 1 limiter wait, 1 retry, 1 parse failure, 1 interstitial, 1 items
+	if count != 10 {
+		t.Errorf("GatherAndCount() = %d, want %d", count, 10)
+	}
+}
@@ -1,21 +1,96 @@
 package goebaykleinanzeigen
 
 import (
+	"errors"
 	"io"
 	"strconv"
 	"strings"
 	"time"
+	// the dates on the site are in Europe/Berlin, which has to be available without a system time zone database
+	_ "time/tzdata"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
-func parseListHTML(body io.Reader) (*AdListResponse, error) {
+var berlin, _ = time.LoadLocation("Europe/Berlin")
+
+// Strictness decides how ParseAdList and ParseAd handle fields which could not be parsed
+type Strictness int
+
+const (
+	// Lenient leaves fields empty which could not be parsed, this is the default
+	Lenient Strictness = iota
+	// Strict returns a *ParseError for the first field which could not be parsed
+	Strict
+)
+
+var errMissing = errors.New("element missing")
+
+// ParseOption configures ParseAdList and ParseAd
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	baseURL    string
+	strictness Strictness
+	reference  time.Time
+	err        error
+}
+
+// WithParseBaseURL sets the URL the links of the ads are built with, default is DefaultBaseURL
+func WithParseBaseURL(base string) ParseOption {
+	return func(po *parseOptions) {
+		po.baseURL = strings.TrimRight(base, "/")
+	}
+}
+
+// WithStrictness sets the Strictness, default is Lenient
+func WithStrictness(strictness Strictness) ParseOption {
+	return func(po *parseOptions) {
+		po.strictness = strictness
+	}
+}
+
+// WithReferenceTime sets the time relative dates like "Heute, 14:05" are resolved against, default is the current time
+func WithReferenceTime(reference time.Time) ParseOption {
+	return func(po *parseOptions) {
+		po.reference = reference
+	}
+}
+
+func newParseOptions(opts []ParseOption) *parseOptions {
+	po := &parseOptions{
+		baseURL:   DefaultBaseURL,
+		reference: time.Now(),
+	}
+
+	for _, opt := range opts {
+		opt(po)
+	}
+
+	return po
+}
+
+// check remembers the first failed field in strict mode
+func (po *parseOptions) check(selector string, err error) {
+	if po.strictness == Strict && po.err == nil && err != nil {
+		po.err = &ParseError{Selector: selector, Err: err}
+	}
+}
+
+// ParseAdList parses a search result page, e.g. a saved page of an archive.
+// Block, captcha, consent and maintenance pages are returned as *InterstitialError
+func ParseAdList(body io.Reader, opts ...ParseOption) (*AdListResponse, error) {
+	po := newParseOptions(opts)
 	doc, err := goquery.NewDocumentFromReader(body)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkInterstitial(doc); err != nil {
+		return nil, err
+	}
+
 	selector := doc.Find(".aditem")
 	listItems := make([]*AdListItem, 0, len(selector.Nodes))
 
@@ -28,13 +103,16 @@ func parseListHTML(body io.Reader) (*AdListResponse, error) {
 
 		if id, ok := s.Attr("data-adid"); ok {
 			listItem.ID = id
-			listItem.Link = baseURL + "/s-anzeige/" + listItem.ID
+			listItem.Link = po.baseURL + "/s-anzeige/" + listItem.ID
+		} else {
+			po.check(".aditem[data-adid]", errMissing)
 		}
 
 		listItem.Title = s.Find(".ellipsis").First().Text()
 
 		priceText := strings.TrimSpace(s.Find(".aditem-main--middle--price").First().Text())
-		price, negotiable, _ := parsePrice(priceText)
+		price, negotiable, err := parsePrice(priceText)
+		po.check(".aditem-main--middle--price", err)
 
 		listItem.PriceNegotiable = negotiable
 		listItem.Price = price
@@ -45,13 +123,49 @@ func parseListHTML(body io.Reader) (*AdListResponse, error) {
 		listItem.Location = location
 		listItem.ZipCode = zip
 
+		if distance, ok := parseDistance(locationText); ok {
+			listItem.Distance = &distance
+		}
+
+		imageBox := s.Find(".imagebox.srpimagebox").First()
+		listItem.Thumbnail = imageBox.AttrOr("data-imgsrc", "")
+		listItem.ThumbnailRetina = parseSrcset(imageBox.AttrOr("data-imgsrcretina", ""))
+		listItem.ImageCount = parseImageCount(imageBox, listItem.Thumbnail)
+
+		listItem.Snippet = strings.TrimSpace(s.Find(".aditem-main--middle--description").First().Text())
+
+		postedAt, err := parsePostedAt(strings.TrimSpace(s.Find(".aditem-main--top--right").First().Text()), po.reference)
+		listItem.PostedAt = postedAt
+		po.check(".aditem-main--top--right", err)
+
+		tagsSelector := s.Find(".simpletag.tag-small")
+		listItem.Tags = make([]string, 0, len(tagsSelector.Nodes))
+
+		tagsSelector.Each(func(_ int, s *goquery.Selection) {
+			listItem.Tags = append(listItem.Tags, strings.TrimSpace(s.Text()))
+		})
+
+		parseTags(listItem)
+
+		listItem.Placement, listItem.InsideRadius = parsePlacement(s)
+
 		response.Items = append(response.Items, listItem)
 	})
 
+	total, first, last, hasSummary := parseSummary(doc)
+
+	if len(response.Items) == 0 && doc.Find(".pagination-current").Length() == 0 && hasSummary && total == 0 {
+		response.IsLastPage = true
+		response.CurrentPage = 1
+		response.LastPage = 1
+
+		return response, nil
+	}
+
 	currentPageStr := doc.Find(".pagination-current").First().Text()
 	currentPage, err := strconv.ParseInt(currentPageStr, 10, 32)
 	if err != nil {
-		return nil, err
+		return nil, &ParseError{Selector: ".pagination-current", Err: err}
 	}
 
 	lastPageStr := doc.Find(".pagination-page").Last().Text()
@@ -62,30 +176,70 @@ func parseListHTML(body io.Reader) (*AdListResponse, error) {
 		lastPage, err = strconv.ParseInt(lastPageStr, 10, 32)
 
 		if err != nil {
-			return nil, err
+			return nil, &ParseError{Selector: ".pagination-page", Err: err}
 		}
 	}
 
 	response.IsLastPage = currentPage >= lastPage
+	response.CurrentPage = int(currentPage)
+	response.LastPage = int(lastPage)
+
+	if lastPage < currentPage {
+		response.LastPage = int(currentPage)
+	}
+
+	response.TotalCount = total
+
+	switch {
+	case currentPage > 1 && first > 1:
+		// the index of the first result tells the page size, the last page may be shorter
+		response.PerPage = (first - 1) / int(currentPage-1)
+	case first > 0 && last >= first:
+		response.PerPage = last - first + 1
+	default:
+		// top ads and results from the surrounding area are not part of the page size,
+		// highlighted and PRO items are regular results
+		for _, item := range response.Items {
+			if item.Placement != TopPlacement && item.InsideRadius {
+				response.PerPage++
+			}
+		}
+	}
+
+	if po.err != nil {
+		return nil, po.err
+	}
 
 	return response, nil
 }
 
-func parseAdHTML(body io.Reader) (*AdItem, error) {
+// ParseAd parses the page of a single ad, e.g. a saved page of an archive.
+// Block, captcha, consent and maintenance pages are returned as *InterstitialError
+func ParseAd(body io.Reader, opts ...ParseOption) (*AdItem, error) {
+	po := newParseOptions(opts)
 	doc, err := goquery.NewDocumentFromReader(body)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkInterstitial(doc); err != nil {
+		return nil, err
+	}
+
 	ad := &AdItem{}
 	seller := &Seller{}
 	ad.Seller = seller
 
 	ad.Title = strings.TrimSpace(doc.Find("#viewad-title").First().Text())
 
+	if ad.Title == "" {
+		po.check("#viewad-title", errMissing)
+	}
+
 	priceText := strings.TrimSpace(doc.Find("#viewad-price").First().Text())
-	price, negotiable, _ := parsePrice(priceText)
+	price, negotiable, err := parsePrice(priceText)
+	po.check("#viewad-price", err)
 
 	ad.PriceNegotiable = negotiable
 	ad.Price = price
@@ -97,13 +251,17 @@ func parseAdHTML(body io.Reader) (*AdItem, error) {
 	ad.ZipCode = zip
 
 	// It's not directly possible to scrape the number of views from the html directly since its injected by javascript.
-	// The current number of views are available under https://www.ebay-kleinanzeigen.de/s-vac-inc-get.json?adId={ID}
+	// The current number of views are available under https://www.kleinanzeigen.de/s-vac-inc-get.json?adId={ID}
 	// fmt.Println(doc.Find(".textcounter").Html())
 	date, id := parseExtraInfo(strings.TrimSpace(doc.Find("#viewad-extra-info").First().Text()))
 
 	ad.ListedSince = date
 	ad.ID = id
-	ad.Link = baseURL + "/s-anzeige/" + id
+	ad.Link = po.baseURL + "/s-anzeige/" + id
+
+	if id == "" {
+		po.check("#viewad-extra-info", errMissing)
+	}
 
 	detailsSelector := doc.Find(".addetailslist--detail")
 	ad.Details = make([]*Detail, 0, len(detailsSelector.Nodes))
@@ -111,6 +269,11 @@ func parseAdHTML(body io.Reader) (*AdItem, error) {
 	detailsSelector.Each(func(_ int, s *goquery.Selection) {
 		key, val := parseDetail(strings.TrimSpace(s.Text()))
 
+		if key == "" {
+			po.check(".addetailslist--detail", errMissing)
+			return
+		}
+
 		ad.Details = append(ad.Details, &Detail{
 			Name:  key,
 			Value: val,
@@ -138,8 +301,14 @@ func parseAdHTML(body io.Reader) (*AdItem, error) {
 		seller.ActiveSince = time
 	}
 
+	po.check(".text-light.text-light-seller-info", err)
+
 	ad.Description = strings.TrimSpace(doc.Find("#viewad-description-text").First().Text())
 
+	if po.err != nil {
+		return nil, po.err
+	}
+
 	return ad, nil
 }
 
@@ -189,6 +358,149 @@ func parseLocation(text string) (string, string) {
 	return "", ""
 }
 
+// parseSummary returns the total number of hits and the indexes of the first and the last result on the page.
+// The summary looks like "1.226 - 1.250 von 20.140 BMW Gebrauchtwagen", the header like "20140 Ergebnisse"
+func parseSummary(doc *goquery.Document) (int, int, int, bool) {
+	summary := strings.Fields(doc.Find(".breadcrump-summary").First().Text())
+
+	if len(summary) >= 5 && summary[1] == "-" && summary[3] == "von" {
+		first, firstErr := strconv.Atoi(strings.ReplaceAll(summary[0], ".", ""))
+		last, lastErr := strconv.Atoi(strings.ReplaceAll(summary[2], ".", ""))
+		total, totalErr := strconv.Atoi(strings.ReplaceAll(summary[4], ".", ""))
+
+		if firstErr == nil && lastErr == nil && totalErr == nil {
+			return total, first, last, true
+		}
+	}
+
+	header := strings.TrimSpace(doc.Find(".splitheader--title strong").First().Text())
+
+	if total, err := strconv.Atoi(strings.ReplaceAll(header, ".", "")); err == nil {
+		return total, 0, 0, true
+	}
+
+	return 0, 0, 0, false
+}
+
+// parseSrcset strips the pixel density descriptor like "2x" from the URL
+func parseSrcset(text string) string {
+	splits := strings.Fields(text)
+
+	if len(splits) == 0 {
+		return ""
+	}
+
+	return splits[0]
+}
+
+// parseImageCount reads the counter of the image box, ads with a single image have no counter
+func parseImageCount(imageBox *goquery.Selection, thumbnail string) int {
+	counter := strings.TrimSpace(imageBox.Find(".galleryimage--counter").First().Text())
+
+	if count, err := strconv.Atoi(counter); err == nil {
+		return count
+	}
+
+	if thumbnail == "" {
+		return 0
+	}
+
+	return 1
+}
+
+// parseTags sets the typed values of the tags.
+// A plain year like "2004" is only taken as first registration if the item also has a mileage,
+// in other categories it may mean something else
+func parseTags(item *AdListItem) {
+	year := 0
+
+	for _, tag := range item.Tags {
+		switch {
+		case tag == "Versand möglich":
+			item.Shipping = true
+		case tag == "Direkt kaufen":
+			item.BuyNow = true
+		case strings.HasSuffix(tag, " km"):
+			if km, err := strconv.Atoi(strings.ReplaceAll(strings.TrimSuffix(tag, " km"), ".", "")); err == nil {
+				item.Mileage = km
+			}
+		case strings.HasPrefix(tag, "EZ "):
+			if date, err := time.Parse("01/2006", strings.TrimPrefix(tag, "EZ ")); err == nil {
+				item.RegistrationYear = date.Year()
+				item.RegistrationMonth = int(date.Month())
+			}
+		case len(tag) == 4:
+			if parsed, err := strconv.Atoi(tag); err == nil {
+				year = parsed
+			}
+		}
+	}
+
+	if item.RegistrationYear == 0 && item.Mileage > 0 {
+		item.RegistrationYear = year
+	}
+}
+
+// parsePostedAt parses "Heute, 14:05", "Gestern, 09:12" or "21.03.2021" in Europe/Berlin relative to reference.
+// The time is returned in UTC, an empty text results in the zero time
+func parsePostedAt(text string, reference time.Time) (time.Time, error) {
+	if text == "" {
+		return time.Time{}, nil
+	}
+
+	reference = reference.In(berlin)
+	splits := strings.SplitN(text, ",", 2)
+
+	var day time.Time
+
+	switch splits[0] {
+	case "Heute":
+		day = time.Date(reference.Year(), reference.Month(), reference.Day(), 0, 0, 0, 0, berlin)
+	case "Gestern":
+		day = time.Date(reference.Year(), reference.Month(), reference.Day()-1, 0, 0, 0, 0, berlin)
+	default:
+		date, err := time.ParseInLocation("02.01.2006", splits[0], berlin)
+
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		day = date
+	}
+
+	if len(splits) == 1 {
+		return day.UTC(), nil
+	}
+
+	clock, err := time.Parse("15:04", strings.TrimSpace(splits[1]))
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	posted := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, berlin)
+
+	return posted.UTC(), nil
+}
+
+// parseDistance parses the distance like "(13 km)" following the location
+func parseDistance(text string) (int, bool) {
+	open := strings.LastIndex(text, "(")
+	end := strings.LastIndex(text, "km)")
+
+	if open < 0 || end < open {
+		return 0, false
+	}
+
+	distance, err := strconv.Atoi(strings.TrimSpace(text[open+1 : end]))
+
+	if err != nil {
+		return 0, false
+	}
+
+	return distance, true
+}
+
 func parseExtraInfo(text string) (time.Time, string) {
 	text = strings.ReplaceAll(text, "\n", "")
 	splits := strings.SplitN(text, " ", 2)
@@ -205,8 +517,14 @@ func parseExtraInfo(text string) (time.Time, string) {
 	return time.Time{}, ""
 }
 
+// parseDetail splits a detail into name and value, both are empty if the text has no value
 func parseDetail(text string) (string, string) {
 	splits := strings.SplitN(text, "\n", 2)
+
+	if len(splits) != 2 {
+		return "", ""
+	}
+
 	return strings.TrimSpace(splits[0]), strings.TrimSpace(splits[1])
 }
 
@@ -221,6 +539,11 @@ func parseActiveSince(text string) (time.Time, error) {
 
 	strip := "Aktiv seit "
 	index := strings.Index(text, strip)
+
+	if index < 0 {
+		return time.Time{}, errMissing
+	}
+
 	text = text[index+len(strip):]
 	splits := strings.Split(text, "\n")
 
@@ -3,12 +3,15 @@ package goebaykleinanzeigen
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 func timeCanPanic(t *testing.T, text string) time.Time {
@@ -101,6 +104,30 @@ func Test_parseLocation(t *testing.T) {
 	}
 }
 
+func Test_parseDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		distance int
+		ok       bool
+	}{
+		{name: "distance", text: "16540 Hohen Neuendorf\n(13km)", distance: 13, ok: true},
+		{name: "distance-space", text: "10119 Mitte\n\t\t(0 km)", distance: 0, ok: true},
+		{name: "no-distance", text: "16540 Hohen Neuendorf", ok: false},
+		{name: "brackets-in-location", text: "57610 Altenkirchen (Westerwald)", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance, ok := parseDistance(tt.text)
+
+			if distance != tt.distance || ok != tt.ok {
+				t.Errorf("parseDistance() = %v, %v, want %v, %v", distance, ok, tt.distance, tt.ok)
+			}
+		})
+	}
+}
+
 func Test_parseExtraInfo(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -154,6 +181,12 @@ func Test_parseDetail(t *testing.T) {
 			key:   "Fahrzeugzustand",
 			value: "Unbeschädigtes Fahrzeug",
 		},
+		{
+			name:  "no-value",
+			text:  "Kilometerstand",
+			key:   "",
+			value: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -201,20 +234,31 @@ func Test_parseActiveSince(t *testing.T) {
 		name        string
 		text        string
 		activeSince time.Time
+		wantErr     bool
 	}{
 		{
 			name:        "normal",
 			text:        "Aktiv seit 06.10.2012",
 			activeSince: timeCanPanic(t, "06.10.2012"),
 		},
+		{
+			name:    "short-without-prefix",
+			text:    "Privat",
+			wantErr: true,
+		},
+		{
+			name:    "without-prefix",
+			text:    "Gewerblicher Anbieter",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			activeSince, err := parseActiveSince(tt.text)
 
-			if err != nil {
-				t.Errorf("did not expect error, got %w", err)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseActiveSince() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
 			if activeSince != tt.activeSince {
@@ -224,7 +268,7 @@ func Test_parseActiveSince(t *testing.T) {
 	}
 }
 
-func Test_parseAdHTML(t *testing.T) {
+func Test_ParseAd(t *testing.T) {
 	files, err := filepath.Glob("testdata/aditem/*.html")
 
 	if err != nil {
@@ -253,20 +297,20 @@ func Test_parseAdHTML(t *testing.T) {
 			adItem := &AdItem{}
 			_ = json.Unmarshal(jsonRaw, adItem)
 
-			returnedItem, err := parseAdHTML(bytes.NewReader(html))
+			returnedItem, err := ParseAd(bytes.NewReader(html), WithParseBaseURL(LegacyBaseURL), WithStrictness(Strict))
 
 			if err != nil {
 				t.Fatal(err)
 			}
 
 			if !reflect.DeepEqual(returnedItem, adItem) {
-				t.Errorf("parseAdHTML() got = %v, want %v", returnedItem, adItem)
+				t.Errorf("ParseAd() got = %v, want %v", returnedItem, adItem)
 			}
 		})
 	}
 }
 
-func Test_parseListHTML(t *testing.T) {
+func Test_ParseAdList(t *testing.T) {
 	files, err := filepath.Glob("testdata/adlist/*.html")
 
 	if err != nil {
@@ -295,7 +339,12 @@ func Test_parseListHTML(t *testing.T) {
 			list := &AdListResponse{}
 			_ = json.Unmarshal(jsonRaw, list)
 
-			returnedList, err := parseListHTML(bytes.NewReader(html))
+			returnedList, err := ParseAdList(bytes.NewReader(html),
+				WithParseBaseURL(LegacyBaseURL),
+				WithStrictness(Strict),
+				// resolve "Gestern" against a fixed day
+				WithReferenceTime(time.Date(2021, 3, 24, 12, 0, 0, 0, berlin)),
+			)
 
 			if err != nil {
 				t.Fatal(err)
@@ -305,8 +354,243 @@ func Test_parseListHTML(t *testing.T) {
 				returnedListDbg, _ := json.MarshalIndent(returnedList, "", "	")
 				t.Log(string(returnedListDbg))
 
-				t.Errorf("parseAdHTML() got = %v, want %v", returnedList, list)
+				t.Errorf("ParseAdList() got = %v, want %v", returnedList, list)
+			}
+		})
+	}
+}
+
+func Test_ParseAdListStrictness(t *testing.T) {
+	html := strings.Replace(listPageHTML(1, 1, "1"), "1.000 € VB", "ab 1.000 €", 1)
+
+	list, err := ParseAdList(strings.NewReader(html), WithParseBaseURL("http://localhost/"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if list.Items[0].Price != 0 || list.Items[0].Link != "http://localhost/s-anzeige/1" {
+		t.Errorf("ParseAdList() got = %+v", list.Items[0])
+	}
+
+	_, err = ParseAdList(strings.NewReader(html), WithStrictness(Strict))
+
+	var pe *ParseError
+
+	if !errors.As(err, &pe) || pe.Selector != ".aditem-main--middle--price" {
+		t.Errorf("ParseAdList() error = %v, want ParseError at .aditem-main--middle--price", err)
+	}
+}
+
+func Test_parseImageCount(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want int
+	}{
+		{name: "counter", html: `<div class="imagebox srpimagebox" data-imgsrc="a.jpg"><div class="galleryimage--counter">7</div></div>`, want: 7},
+		{name: "single", html: `<div class="imagebox srpimagebox" data-imgsrc="a.jpg"></div>`, want: 1},
+		{name: "none", html: `<div class="imagebox srpimagebox"></div>`, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			imageBox := doc.Find(".imagebox").First()
+
+			if got := parseImageCount(imageBox, imageBox.AttrOr("data-imgsrc", "")); got != tt.want {
+				t.Errorf("parseImageCount() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_parsePostedAt(t *testing.T) {
+	reference := time.Date(2021, 3, 28, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		text    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "today", text: "Heute, 14:05", want: time.Date(2021, 3, 29, 12, 5, 0, 0, time.UTC)},
+		{name: "yesterday-before-dst", text: "Gestern, 01:12", want: time.Date(2021, 3, 28, 0, 12, 0, 0, time.UTC)},
+		{name: "date", text: "21.03.2021", want: time.Date(2021, 3, 20, 23, 0, 0, 0, time.UTC)},
+		{name: "empty", text: "", want: time.Time{}},
+		{name: "invalid", text: "Vorgestern", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePostedAt(tt.text, reference)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePostedAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("parsePostedAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want AdListItem
+	}{
+		{
+			name: "car",
+			tags: []string{"189.000 km", "EZ 04/2008", "Versand möglich", "Direkt kaufen"},
+			want: AdListItem{Mileage: 189000, RegistrationYear: 2008, RegistrationMonth: 4, Shipping: true, BuyNow: true},
+		},
+		{
+			name: "car-year",
+			tags: []string{"196.500 km", "1994"},
+			want: AdListItem{Mileage: 196500, RegistrationYear: 1994},
+		},
+		{
+			name: "year-without-mileage",
+			tags: []string{"2004", "Nur Abholung"},
+			want: AdListItem{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &AdListItem{Tags: tt.tags}
+			parseTags(item)
+
+			tt.want.Tags = tt.tags
+
+			if !reflect.DeepEqual(*item, tt.want) {
+				t.Errorf("parseTags() got = %+v, want %+v", *item, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ParseAdListPagination(t *testing.T) {
+	summary := func(text string) string {
+		return `<span class="breadcrump-summary">` + text + `</span>`
+	}
+
+	topAd := strings.Replace(listPageHTML(1, 3, "0", "1", "2"), `class="aditem"`, `class="aditem is-topad"`, 1)
+
+	tests := []struct {
+		name  string
+		html  string
+		want  *AdListResponse
+		items int
+	}{
+		{
+			name:  "first-page",
+			html:  strings.Replace(listPageHTML(1, 3, "1", "2"), "<body>", "<body>"+summary("1 - 2 von 5 Ergebnissen"), 1),
+			want:  &AdListResponse{TotalCount: 5, CurrentPage: 1, LastPage: 3, PerPage: 2},
+			items: 2,
+		},
+		{
+			name:  "first-page-with-top-ad",
+			html:  strings.Replace(topAd, "<body>", "<body>"+summary("1 - 2 von 5 Ergebnissen"), 1),
+			want:  &AdListResponse{TotalCount: 5, CurrentPage: 1, LastPage: 3, PerPage: 2},
+			items: 3,
+		},
+		{
+			name:  "first-page-with-top-ad-without-summary",
+			html:  topAd,
+			want:  &AdListResponse{CurrentPage: 1, LastPage: 3, PerPage: 2},
+			items: 3,
+		},
+		{
+			name:  "last-page",
+			html:  strings.Replace(listPageHTML(3, 3, "5"), "<body>", "<body>"+summary("5 - 5 von 5 Ergebnissen"), 1),
+			want:  &AdListResponse{IsLastPage: true, TotalCount: 5, CurrentPage: 3, LastPage: 3, PerPage: 2},
+			items: 1,
+		},
+		{
+			name: "no-hits",
+			html: `<html><body><div id="consentBanner"></div>
+				<div class="splitheader--title"><h2>Suchergebnisse</h2><span><strong>0</strong> Ergebnisse</span></div>
+				` + summary("0 Ergebnisse für „xyz“") + `</body></html>`,
+			want:  &AdListResponse{IsLastPage: true, CurrentPage: 1, LastPage: 1},
+			items: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := ParseAdList(strings.NewReader(tt.html))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := &AdListResponse{
+				IsLastPage:  list.IsLastPage,
+				TotalCount:  list.TotalCount,
+				CurrentPage: list.CurrentPage,
+				LastPage:    list.LastPage,
+				PerPage:     list.PerPage,
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAdList() got = %+v, want %+v", got, tt.want)
+			}
+
+			if list.Items == nil || len(list.Items) != tt.items {
+				t.Errorf("ParseAdList() Items = %v, want %v items", list.Items, tt.items)
+			}
+		})
+	}
+}
+
+func Test_ParseAdMalformed(t *testing.T) {
+	html := `<html><body>
+		<h1 id="viewad-title">Title</h1>
+		<div id="viewad-extra-info"><span>21.03.2021</span> Anzeigennr.: 1</div>
+		<li class="addetailslist--detail">Kilometerstand</li>
+		<div id="viewad-contact"><span class="text-light text-light-seller-info">Privat</span></div>
+	</body></html>`
+
+	ad, err := ParseAd(strings.NewReader(html))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ad.Details) != 0 || !ad.Seller.ActiveSince.IsZero() {
+		t.Errorf("ParseAd() got = %+v, %+v", ad.Details, ad.Seller)
+	}
+
+	_, err = ParseAd(strings.NewReader(html), WithStrictness(Strict))
+
+	var pe *ParseError
+
+	if !errors.As(err, &pe) || pe.Selector != ".addetailslist--detail" {
+		t.Errorf("ParseAd() error = %v, want ParseError at .addetailslist--detail", err)
+	}
+}
+
+func Test_ParseAdStrictActiveSince(t *testing.T) {
+	html := `<html><body>
+		<h1 id="viewad-title">Title</h1>
+		<div id="viewad-extra-info"><span>21.03.2021</span> Anzeigennr.: 1</div>
+		<div id="viewad-contact"><span class="text-light text-light-seller-info">Privat</span></div>
+	</body></html>`
+
+	_, err := ParseAd(strings.NewReader(html), WithStrictness(Strict))
+
+	var pe *ParseError
+
+	if !errors.As(err, &pe) || pe.Selector != ".text-light.text-light-seller-info" {
+		t.Errorf("ParseAd() error = %v, want ParseError at .text-light.text-light-seller-info", err)
+	}
+}
@@ -0,0 +1,112 @@
+package goebaykleinanzeigen
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Placement describes how an item got its position in the result list
+type Placement string
+
+const (
+	// RegularPlacement is an item sorted by the search
+	RegularPlacement Placement = ""
+	// TopPlacement is a paid top ad, it is repeated on every page
+	TopPlacement Placement = "top"
+	// HighlightPlacement is a paid highlighted item
+	HighlightPlacement Placement = "highlight"
+	// ProPlacement is an item of a commercial PRO partner
+	ProPlacement Placement = "pro"
+)
+
+var (
+	topAdSelectors = []string{
+		".is-topad",
+		".badge-topad",
+	}
+
+	highlightSelectors = []string{
+		".is-highlight",
+		".badge-highlight",
+	}
+
+	proSelectors = []string{
+		"[class*='badge-hint-pro']",
+		".is-pro",
+	}
+
+	// outsideRadiusSelectors mark the list of alternative results from the surrounding area
+	outsideRadiusSelectors = []string{
+		"#srchrslt-adtable-altads",
+		".altads",
+	}
+)
+
+// ExcludePromoted removes top, highlighted and PRO items from the result
+func ExcludePromoted() FetchOption {
+	return func(fo *fetchOptions) {
+		fo.excludePromoted = true
+	}
+}
+
+// ExcludeOutsideRadius removes alternative results from outside the requested radius
+func ExcludeOutsideRadius() FetchOption {
+	return func(fo *fetchOptions) {
+		fo.excludeOutsideRadius = true
+	}
+}
+
+// parsePlacement returns the placement of an .aditem and whether it is inside the requested radius.
+// An item which is top and PRO at the same time is reported as top
+func parsePlacement(s *goquery.Selection) (Placement, bool) {
+	// the markers may be set on the item or on the surrounding list item
+	item := s.AddSelection(s.Closest(".ad-listitem"))
+	placement := RegularPlacement
+
+	switch {
+	case matchesAny(item, topAdSelectors):
+		placement = TopPlacement
+	case matchesAny(item, highlightSelectors):
+		placement = HighlightPlacement
+	case matchesAny(item, proSelectors):
+		placement = ProPlacement
+	}
+
+	insideRadius := s.Closest(strings.Join(outsideRadiusSelectors, ", ")).Length() == 0
+
+	return placement, insideRadius
+}
+
+func matchesAny(s *goquery.Selection, selectors []string) bool {
+	for _, sel := range selectors {
+		if s.Is(sel) || s.Find(sel).Length() > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterItems removes the items excluded by the fetch options
+func filterItems(items []*AdListItem, fo *fetchOptions) []*AdListItem {
+	if !fo.excludePromoted && !fo.excludeOutsideRadius {
+		return items
+	}
+
+	filtered := make([]*AdListItem, 0, len(items))
+
+	for _, item := range items {
+		if fo.excludePromoted && item.Placement != RegularPlacement {
+			continue
+		}
+
+		if fo.excludeOutsideRadius && !item.InsideRadius {
+			continue
+		}
+
+		filtered = append(filtered, item)
+	}
+
+	return filtered
+}
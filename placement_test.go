@@ -0,0 +1,157 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testdata/adlist/promoted-page.html is the first page of the search in last-page.html.
+// It starts with two top ads, has a highlighted and a PRO item between the regular results
+// and ends with two alternative results from the surrounding area
+func Test_AdListPlacement(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/adlist/promoted-page.html")
+	}))
+	defer srv.Close()
+
+	al := NewClient(WithoutRateLimit(), WithBaseURL(srv.URL)).AdListRepo()
+
+	type placed struct {
+		ID           string
+		Placement    Placement
+		InsideRadius bool
+	}
+
+	tests := []struct {
+		name string
+		opts []FetchOption
+		// items is the number of returned items
+		items int
+		// special are the items which are not regular results inside the radius
+		special []placed
+	}{
+		{
+			name:  "all",
+			items: 29,
+			special: []placed{
+				{ID: "1712886749", Placement: TopPlacement, InsideRadius: true},
+				{ID: "1712883470", Placement: TopPlacement, InsideRadius: true},
+				{ID: "1712895539", Placement: HighlightPlacement, InsideRadius: true},
+				{ID: "1712894967", Placement: ProPlacement, InsideRadius: true},
+				{ID: "1712871135", Placement: RegularPlacement, InsideRadius: false},
+				{ID: "1712871003", Placement: RegularPlacement, InsideRadius: false},
+			},
+		},
+		{
+			name:  "exclude-promoted",
+			opts:  []FetchOption{ExcludePromoted()},
+			items: 25,
+			special: []placed{
+				{ID: "1712871135", Placement: RegularPlacement, InsideRadius: false},
+				{ID: "1712871003", Placement: RegularPlacement, InsideRadius: false},
+			},
+		},
+		{
+			name:    "exclude-promoted-and-outside",
+			opts:    []FetchOption{ExcludePromoted(), ExcludeOutsideRadius()},
+			items:   23,
+			special: []placed{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := al.Fetch(context.Background(), &SearchParam{}, tt.opts...)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(list.Items) != tt.items {
+				t.Errorf("len(Items) = %v, want %v", len(list.Items), tt.items)
+			}
+
+			special := []placed{}
+
+			for _, item := range list.Items {
+				if item.Placement != RegularPlacement || !item.InsideRadius {
+					special = append(special, placed{ID: item.ID, Placement: item.Placement, InsideRadius: item.InsideRadius})
+				}
+			}
+
+			if !reflect.DeepEqual(special, tt.special) {
+				t.Errorf("special items = %+v, want %+v", special, tt.special)
+			}
+
+			// the filters don't change the page size
+			if list.CurrentPage != 1 || list.LastPage != 50 || list.TotalCount != 20140 || list.PerPage != 25 {
+				t.Errorf("pagination = %v/%v, total = %v, per page = %v", list.CurrentPage, list.LastPage, list.TotalCount, list.PerPage)
+			}
+		})
+	}
+}
+
+func Test_ParseAdListPlacementFixture(t *testing.T) {
+	f, err := os.Open("testdata/adlist/promoted-page.html")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	list, err := ParseAdList(f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[Placement]int{}
+	outside := 0
+
+	for _, item := range list.Items {
+		counts[item.Placement]++
+
+		if !item.InsideRadius {
+			outside++
+		}
+	}
+
+	want := map[Placement]int{TopPlacement: 2, HighlightPlacement: 1, ProPlacement: 1, RegularPlacement: 25}
+
+	if !reflect.DeepEqual(counts, want) || outside != 2 {
+		t.Errorf("placements = %v, outside = %v, want %v and 2 outside", counts, outside, want)
+	}
+
+	// the top ads and the alternative results are not part of the 25 results per page
+	if list.PerPage != 25 {
+		t.Errorf("PerPage = %v, want %v", list.PerPage, 25)
+	}
+}
+
+func Test_ParseAdListPlacementPerPageWithoutSummary(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/adlist/promoted-page.html")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without the summary the page size is counted from the items
+	html := strings.Replace(string(b), "breadcrump-summary", "breadcrump-removed", 1)
+
+	list, err := ParseAdList(strings.NewReader(html))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if list.PerPage != 25 {
+		t.Errorf("PerPage = %v, want %v", list.PerPage, 25)
+	}
+}
@@ -0,0 +1,148 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func newTestProxy(t *testing.T, name string, status int) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(name))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func fetchThroughPool(t *testing.T, client *http.Client) (string, int) {
+	t.Helper()
+
+	req, _ := http.NewRequestWithContext(context.Background(), "GET", "http://kleinanzeigen.invalid/s-anzeige/1", nil)
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	return string(body), resp.StatusCode
+}
+
+func Test_ProxyPoolRoundRobin(t *testing.T) {
+	a := newTestProxy(t, "a", 200)
+	b := newTestProxy(t, "b", 200)
+
+	pool, err := NewProxyPool([]string{a.URL, b.URL}, WithProxyRateLimit(rate.Inf, 1))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: pool}
+	want := []string{"a", "b", "a", "b"}
+
+	for i, w := range want {
+		if got, _ := fetchThroughPool(t, client); got != w {
+			t.Errorf("request %d used proxy %v, want %v", i, got, w)
+		}
+	}
+}
+
+func Test_ProxyPoolQuarantine(t *testing.T) {
+	good := newTestProxy(t, "good", 200)
+	bad := newTestProxy(t, "bad", 429)
+
+	now := time.Date(2021, 3, 22, 12, 0, 0, 0, time.UTC)
+
+	pool, err := NewProxyPool(
+		[]string{bad.URL, good.URL},
+		WithProxyStrategy(LeastUsed),
+		WithProxyRateLimit(rate.Inf, 1),
+		WithQuarantine(2, time.Minute),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool.now = func() time.Time { return now }
+	client := &http.Client{Transport: pool}
+
+	for i := 0; i < 4; i++ {
+		fetchThroughPool(t, client)
+	}
+
+	stats := pool.Stats()
+
+	if !stats[0].QuarantinedUntil.Equal(now.Add(time.Minute)) {
+		t.Errorf("bad proxy quarantined until %v, want %v", stats[0].QuarantinedUntil, now.Add(time.Minute))
+	}
+
+	for i := 0; i < 3; i++ {
+		if got, _ := fetchThroughPool(t, client); got != "good" {
+			t.Errorf("quarantined proxy was used")
+		}
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	if got, status := fetchThroughPool(t, client); got != "bad" || status != 429 {
+		t.Errorf("expected bad proxy after cool-down, got %v", got)
+	}
+
+	pool.proxies[1].quarantinedUntil = now.Add(time.Hour)
+	pool.proxies[0].quarantinedUntil = now.Add(time.Hour)
+
+	_, err = client.Get("http://kleinanzeigen.invalid/")
+
+	if !errors.Is(err, ErrNoProxyAvailable) {
+		t.Errorf("expected ErrNoProxyAvailable, got %v", err)
+	}
+}
+
+func Test_ProxyPoolBlockPage(t *testing.T) {
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/interstitial/blocked.html")
+	}))
+	defer blocked.Close()
+
+	pool, err := NewProxyPool([]string{blocked.URL}, WithProxyRateLimit(rate.Inf, 1), WithQuarantine(2, time.Minute))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ar := NewClient(
+		WithHTTPClient(&http.Client{Transport: pool}),
+		WithoutRateLimit(),
+		WithBaseURL("http://kleinanzeigen.invalid"),
+	).AdRepo()
+
+	for i := 0; i < 2; i++ {
+		if _, err := ar.Fetch(context.Background(), "/s-anzeige/1"); !errors.Is(err, ErrBlocked) {
+			t.Fatalf("expected ErrBlocked, got %v", err)
+		}
+	}
+
+	stats := pool.Stats()
+
+	if stats[0].Failures != 2 || stats[0].QuarantinedUntil.IsZero() {
+		t.Errorf("proxy stats = %+v, want 2 failures and a quarantine", stats[0])
+	}
+
+	if _, err := ar.Fetch(context.Background(), "/s-anzeige/1"); !errors.Is(err, ErrNoProxyAvailable) {
+		t.Errorf("expected ErrNoProxyAvailable, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package goebaykleinanzeigen
+
+import (
+	"net/http"
+	"time"
+)
+
+// ParserVersion is increased whenever the output of the parsers changes
+const ParserVersion = "8"
+
+// RawPage is the page a result was parsed from, it allows to reproduce parser bugs
+type RawPage struct {
+	// URL is the URL after following redirects
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	FetchedAt  time.Time   `json:"fetched_at"`
+	// FromCache reports whether the page was served from the cache, FetchedAt is the time it was stored then
+	FromCache     bool   `json:"from_cache"`
+	ParserVersion string `json:"parser_version"`
+}
+
+// KeepRaw retains the raw page on the result of this call, see RawPage
+func KeepRaw() FetchOption {
+	return func(fo *fetchOptions) {
+		fo.keepRaw = true
+	}
+}
+
+func (p *page) raw() *RawPage {
+	return &RawPage{
+		URL:           p.finalURL,
+		StatusCode:    p.statusCode,
+		Header:        p.header,
+		Body:          p.body,
+		FetchedAt:     p.fetchedAt,
+		FromCache:     p.fromCache,
+		ParserVersion: ParserVersion,
+	}
+}
@@ -0,0 +1,57 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_FetchKeepRaw(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/s-anzeige/old" {
+			http.Redirect(w, r, "/s-anzeige/1707662827", http.StatusMovedPermanently)
+			return
+		}
+
+		w.Header().Set("X-Test", "1")
+		http.ServeFile(w, r, "testdata/aditem/generic-ad.html")
+	}))
+	defer srv.Close()
+
+	ar := NewClient(WithoutRateLimit(), WithBaseURL(srv.URL)).AdRepo()
+
+	item, err := ar.Fetch(context.Background(), "/s-anzeige/old")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item.Raw != nil {
+		t.Errorf("AdItem.Raw is set without KeepRaw")
+	}
+
+	item, err = ar.Fetch(context.Background(), "/s-anzeige/old", KeepRaw())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := item.Raw
+
+	if raw == nil {
+		t.Fatal("AdItem.Raw is not set")
+	}
+
+	if raw.URL != srv.URL+"/s-anzeige/1707662827" {
+		t.Errorf("RawPage.URL = %v, want %v", raw.URL, srv.URL+"/s-anzeige/1707662827")
+	}
+
+	if raw.StatusCode != http.StatusOK || raw.Header.Get("X-Test") != "1" || len(raw.Body) == 0 {
+		t.Errorf("RawPage = %v %v %d bytes", raw.StatusCode, raw.Header, len(raw.Body))
+	}
+
+	if raw.FetchedAt.IsZero() || raw.FromCache || raw.ParserVersion != ParserVersion {
+		t.Errorf("RawPage = %v %v %v", raw.FetchedAt, raw.FromCache, raw.ParserVersion)
+	}
+}
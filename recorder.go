@@ -0,0 +1,284 @@
+package goebaykleinanzeigen
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNoInteraction is returned in replay mode if the cassette has no response for a request
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// RecorderMode decides whether a Recorder records or replays
+type RecorderMode int
+
+const (
+	// Record sends requests and stores the responses in the cassette
+	Record RecorderMode = iota
+	// Replay answers requests from the cassette without any network access
+	Replay
+)
+
+// DefaultScrubHeaders are removed from requests and responses before they are recorded
+var DefaultScrubHeaders = []string{"Cookie", "Set-Cookie", "Authorization", "Proxy-Authorization"}
+
+// Cassette holds recorded request/response pairs
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request/response pair
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the recorded part of a request
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+}
+
+// RecordedResponse is the recorded part of a response
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+	// BodyEncoding is "base64" if the body is not valid UTF-8, e.g. a compressed body, an image or a Latin-1 page
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// BodyBase64 marks a base64 encoded body in a RecordedResponse
+const BodyBase64 = "base64"
+
+// setBody stores body as text if possible, otherwise base64 encoded so the bytes survive the JSON encoding
+func (rr *RecordedResponse) setBody(body []byte) {
+	if utf8.Valid(body) {
+		rr.Body = string(body)
+		rr.BodyEncoding = ""
+
+		return
+	}
+
+	rr.Body = base64.StdEncoding.EncodeToString(body)
+	rr.BodyEncoding = BodyBase64
+}
+
+func (rr *RecordedResponse) body() ([]byte, error) {
+	switch rr.BodyEncoding {
+	case "":
+		return []byte(rr.Body), nil
+	case BodyBase64:
+		return base64.StdEncoding.DecodeString(rr.Body)
+	default:
+		return nil, fmt.Errorf("unknown body encoding %q", rr.BodyEncoding)
+	}
+}
+
+// Recorder is a http.RoundTripper which records sessions into a cassette file and replays them.
+// Requests are matched by method, path and query, so a cassette may be replayed against another host.
+// Recorded requests to the same URL are replayed in order.
+// A Recorder is safe for concurrent use by multiple goroutines
+type Recorder struct {
+	mu        sync.Mutex
+	path      string
+	mode      RecorderMode
+	transport http.RoundTripper
+	scrub     []string
+	cassette  *Cassette
+	replayed  map[string]int
+}
+
+// RecorderOption configures a Recorder
+type RecorderOption func(*Recorder)
+
+// WithRecorderTransport sets the transport used in record mode, default is http.DefaultTransport
+func WithRecorderTransport(transport http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithScrubHeaders replaces DefaultScrubHeaders
+func WithScrubHeaders(headers ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.scrub = headers
+	}
+}
+
+// NewRecorder creates a new Recorder for the cassette at path.
+// In replay mode the cassette has to exist, in record mode it is created by Save
+func NewRecorder(path string, mode RecorderMode, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		scrub:     DefaultScrubHeaders,
+		cassette:  &Cassette{},
+		replayed:  map[string]int{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == Replay {
+		raw, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(raw, r.cassette); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// RoundTrip records or replays the request depending on the mode
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == Replay {
+		return r.replay(req)
+	}
+
+	return r.record(req)
+}
+
+// Save writes the recorded interactions to the cassette file
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	raw, err := json.MarshalIndent(r.cassette, "", "	")
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.path, raw, 0o644)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interaction := &Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: r.scrubbed(req.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.scrubbed(resp.Header),
+		},
+	}
+	interaction.Response.setBody(body)
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := req.Method + " " + req.URL.RequestURI()
+	skip := r.replayed[key]
+	var match, last *Interaction
+
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.Request.Method+" "+requestURI(interaction.Request.URL) != key {
+			continue
+		}
+
+		last = interaction
+
+		if skip == 0 {
+			match = interaction
+			break
+		}
+
+		skip--
+	}
+
+	// once all recorded responses are used up, the last one is repeated
+	if match == nil {
+		match = last
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoInteraction, key)
+	}
+
+	body, err := match.Response.body()
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.replayed[key]++
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", match.Response.StatusCode, http.StatusText(match.Response.StatusCode)),
+		StatusCode:    match.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        match.Response.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) scrubbed(header http.Header) http.Header {
+	return scrubHeader(header, r.scrub)
+}
+
+// scrubHeader returns a copy of header without the given headers
+func scrubHeader(header http.Header, names []string) http.Header {
+	header = header.Clone()
+
+	for _, name := range names {
+		header.Del(name)
+	}
+
+	return header
+}
+
+func requestURI(rawURL string) string {
+	u, err := url.Parse(rawURL)
+
+	if err != nil {
+		return rawURL
+	}
+
+	return u.RequestURI()
+}
@@ -0,0 +1,218 @@
+package goebaykleinanzeigen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// listPageHTML builds a minimal search result page
+func listPageHTML(current, last int, ids ...string) string {
+	sb := strings.Builder{}
+	sb.WriteString(`<html><body><ul id="srchrslt-adtable">`)
+
+	for _, id := range ids {
+		fmt.Fprintf(&sb, `<li><article class="aditem" data-adid="%s">
+			<div class="aditem-main--top--left">10119 Mitte</div>
+			<a class="ellipsis" href="/s-anzeige/%s">Ad %s</a>
+			<p class="aditem-main--middle--price">1.000 € VB</p>
+		</article></li>`, id, id, id)
+	}
+
+	sb.WriteString(`</ul><div class="pagination-pages">`)
+
+	for page := 1; page <= last; page++ {
+		if page == current {
+			fmt.Fprintf(&sb, `<span class="pagination-current">%d</span>`, page)
+		} else {
+			fmt.Fprintf(&sb, `<a class="pagination-page">%d</a>`, page)
+		}
+	}
+
+	sb.WriteString(`</div></body></html>`)
+
+	return sb.String()
+}
+
+func crawl(t *testing.T, client *Client) ([]*AdListResponse, *AdItem) {
+	t.Helper()
+
+	param := &SearchParam{Category: Cars}
+	lists := []*AdListResponse{}
+
+	for {
+		list, err := client.AdListRepo().Fetch(context.Background(), param)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lists = append(lists, list)
+
+		if list.IsLastPage {
+			break
+		}
+
+		param.Page++
+	}
+
+	ad, err := client.AdRepo().Fetch(context.Background(), "/s-anzeige/1707662827")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return lists, ad
+}
+
+func Test_RecorderRoundTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret-session"})
+
+		if strings.HasPrefix(r.URL.Path, "/s-anzeige/") {
+			http.ServeFile(w, r, "testdata/aditem/generic-ad.html")
+			return
+		}
+
+		page := 1
+
+		if i := strings.Index(r.URL.Path, "seite:"); i >= 0 {
+			page, _ = strconv.Atoi(strings.SplitN(r.URL.Path[i+len("seite:"):], "/", 2)[0])
+		}
+
+		_, _ = w.Write([]byte(listPageHTML(page, 2, strconv.Itoa(page*10), strconv.Itoa(page*10+1))))
+	}))
+
+	cassette := filepath.Join(t.TempDir(), "session.json")
+	recorder, err := NewRecorder(cassette, Record)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recording := NewClient(
+		WithoutRateLimit(),
+		WithBaseURL(srv.URL),
+		WithHTTPClient(&http.Client{Transport: recorder}),
+	)
+	recordedLists, recordedAd := crawl(t, recording)
+
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Close()
+
+	raw, err := ioutil.ReadFile(cassette)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(raw), "secret-session") {
+		t.Errorf("cassette contains the session cookie")
+	}
+
+	player, err := NewRecorder(cassette, Replay)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replaying := NewClient(
+		WithoutRateLimit(),
+		WithBaseURL(srv.URL),
+		WithHTTPClient(&http.Client{Transport: player}),
+	)
+	replayedLists, replayedAd := crawl(t, replaying)
+
+	if len(replayedLists) != 2 {
+		t.Errorf("replayed %d list pages, want %d", len(replayedLists), 2)
+	}
+
+	if !reflect.DeepEqual(recordedLists, replayedLists) || !reflect.DeepEqual(recordedAd, replayedAd) {
+		t.Errorf("replayed session differs from the recorded one")
+	}
+
+	_, err = replaying.AdRepo().Fetch(context.Background(), "/s-anzeige/unknown")
+
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction, got %v", err)
+	}
+}
+
+func Test_RecorderBinaryBody(t *testing.T) {
+	bodies := map[string][]byte{
+		"/latin1": []byte("Gr\xf6\xdfe M\xfcnchen"),
+		"/gzip":   {0x1f, 0x8b, 0x08, 0x00, 0xff, 0xfe, 0x00, 0x80},
+		"/text":   []byte("Größe München"),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bodies[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	cassette := filepath.Join(t.TempDir(), "session.json")
+	recorder, err := NewRecorder(cassette, Record)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(client *http.Client, path string) []byte {
+		t.Helper()
+
+		resp, err := client.Get(srv.URL + path)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return body
+	}
+
+	for path := range bodies {
+		get(&http.Client{Transport: recorder}, path)
+	}
+
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	player, err := NewRecorder(cassette, Replay)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range bodies {
+		if got := get(&http.Client{Transport: player}, path); !bytes.Equal(got, want) {
+			t.Errorf("replayed body of %v = %q, want %q", path, got, want)
+		}
+	}
+
+	for _, interaction := range player.cassette.Interactions {
+		text := strings.HasSuffix(interaction.Request.URL, "/text")
+
+		if text != (interaction.Response.BodyEncoding == "") {
+			t.Errorf("body encoding of %v = %q", interaction.Request.URL, interaction.Response.BodyEncoding)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed requests are retried.
+// Only transient failures are retried: timeouts, refused or reset connections and the status codes 429, 500, 502, 503 and 504
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, values below 2 disable retries
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry, it doubles with every further attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A Retry-After header asking for a longer delay ends the retries
+	MaxDelay time.Duration
+	// Jitter randomizes each backoff by up to this fraction (0 to 1) of its length
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used by every Client unless configured otherwise
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   2 * time.Second,
+	MaxDelay:    time.Minute,
+	Jitter:      0.5,
+}
+
+// NoRetry disables retries
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// WithRetryPolicy sets the RetryPolicy for list and ad fetches
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// backoff returns the delay before the given retry, starting at 1
+func (rp RetryPolicy) backoff(retry int) time.Duration {
+	delay := rp.BaseDelay
+
+	for i := 1; i < retry && delay < rp.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if rp.MaxDelay > 0 && delay > rp.MaxDelay {
+		delay = rp.MaxDelay
+	}
+
+	if rp.Jitter > 0 && delay > 0 {
+		jitter := time.Duration(rp.Jitter * float64(delay))
+		delay = delay - jitter + time.Duration(rand.Int63n(int64(2*jitter)+1))
+	}
+
+	return delay
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// isRetryableError reports whether err is a transient network failure.
+// Permanent failures like an invalid URL, TLS errors or an unknown host are not retried
+func isRetryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || isConnectionError(err) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// parseRetryAfter parses the Retry-After header which may either be in seconds or a HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+
+	if err != nil {
+		return 0, false
+	}
+
+	delay := date.Sub(now)
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	return delay, true
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
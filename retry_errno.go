@@ -0,0 +1,16 @@
+//go:build !plan9
+// +build !plan9
+
+package goebaykleinanzeigen
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isConnectionError reports whether the connection was reset, refused or aborted
+func isConnectionError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED)
+}
@@ -0,0 +1,33 @@
+//go:build !plan9
+// +build !plan9
+
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+)
+
+func Test_isRetryableErrorErrno(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "reset", err: &url.Error{Op: "Get", URL: "/", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, want: true},
+		{name: "refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "aborted", err: &net.OpError{Op: "read", Err: syscall.ECONNABORTED}, want: true},
+		{name: "permission", err: &net.OpError{Op: "dial", Err: syscall.EACCES}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(context.Background(), tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package goebaykleinanzeigen
+
+import (
+	"errors"
+	"net"
+)
+
+// isConnectionError reports whether dialing or reading failed, plan9 has no errno values to tell the cause.
+// Failed lookups of unknown hosts are permanent
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+
+	return errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "read") && !errors.As(err, &dnsErr)
+}
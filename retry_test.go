@@ -0,0 +1,227 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 3, 22, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{
+			name:  "empty",
+			value: "",
+			delay: 0,
+			ok:    false,
+		},
+		{
+			name:  "seconds",
+			value: "120",
+			delay: 2 * time.Minute,
+			ok:    true,
+		},
+		{
+			name:  "http-date",
+			value: "Mon, 22 Mar 2021 12:00:30 GMT",
+			delay: 30 * time.Second,
+			ok:    true,
+		},
+		{
+			name:  "date-in-past",
+			value: "Mon, 22 Mar 2021 11:00:00 GMT",
+			delay: 0,
+			ok:    true,
+		},
+		{
+			name:  "invalid",
+			value: "soon",
+			delay: 0,
+			ok:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := parseRetryAfter(tt.value, now)
+			if delay != tt.delay {
+				t.Errorf("parseRetryAfter() delay = %v, want %v", delay, tt.delay)
+			}
+			if ok != tt.ok {
+				t.Errorf("parseRetryAfter() ok = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+}
+
+func Test_RetryPolicyBackoff(t *testing.T) {
+	rp := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+
+	for i, w := range want {
+		if got := rp.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	rp.Jitter = 0.5
+
+	for i := 0; i < 100; i++ {
+		if got := rp.backoff(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Errorf("backoff(1) with jitter = %v, want between 500ms and 1.5s", got)
+		}
+	}
+}
+
+func Test_ClientRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int32
+		wantErr  bool
+	}{
+		{
+			name:     "recovers-after-503",
+			statuses: []int{503, 503, 200},
+			attempts: 3,
+			wantErr:  false,
+		},
+		{
+			name:     "gives-up",
+			statuses: []int{429, 429, 429, 429},
+			attempts: 3,
+			wantErr:  true,
+		},
+		{
+			name:     "not-found-not-retried",
+			statuses: []int{404, 200},
+			attempts: 1,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			client := NewClient(
+				WithoutRateLimit(),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+			)
+
+			_, err := client.get(context.Background(), AdRequest, srv.URL, nil)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if calls != tt.attempts {
+				t.Errorf("get() attempts = %d, want %d", calls, tt.attempts)
+			}
+		})
+	}
+}
+
+func Test_isRetryableError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, schemeErr := http.Get("ftp://example.invalid/s-anzeige/1")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "unexpected-eof", ctx: context.Background(), err: &url.Error{Op: "Get", URL: "/", Err: io.ErrUnexpectedEOF}, want: true},
+		{name: "timeout", ctx: context.Background(), err: &url.Error{Op: "Get", URL: "/", Err: timeoutError{}}, want: true},
+		{name: "unsupported-scheme", ctx: context.Background(), err: schemeErr, want: false},
+		{name: "unknown-host", ctx: context.Background(), err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, want: false},
+		{name: "no-proxy", ctx: context.Background(), err: ErrNoProxyAvailable, want: false},
+		{name: "canceled", ctx: canceled, err: &url.Error{Op: "Get", URL: "/", Err: io.ErrUnexpectedEOF}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func Test_ClientNoRetryOnPermanentError(t *testing.T) {
+	attempts := 0
+
+	ar := NewClient(
+		WithoutRateLimit(),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond}),
+		WithHooks(Hooks{
+			AfterResponse: func(_ context.Context, _ *ResponseInfo) {
+				attempts++
+			},
+		}),
+	).AdRepo()
+
+	if _, err := ar.Fetch(context.Background(), "ftp://example.invalid/s-anzeige/1"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if attempts != 1 {
+		t.Errorf("attempts = %v, want %v", attempts, 1)
+	}
+}
+
+func Test_ClientRetryAfterAboveMaxDelay(t *testing.T) {
+	var calls int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client := NewClient(
+		WithoutRateLimit(),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}),
+	)
+
+	_, err := client.get(context.Background(), AdRequest, srv.URL, nil)
+
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("get() error = %v, want ErrRateLimited", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("get() attempts = %d, want %d", calls, 1)
+	}
+}
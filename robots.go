@@ -0,0 +1,298 @@
+package goebaykleinanzeigen
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrDisallowed is returned if robots.txt disallows the requested path, see RobotsError
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// RobotsError is returned if robots.txt disallows the requested path
+type RobotsError struct {
+	URL string
+}
+
+func (re *RobotsError) Error() string {
+	return ErrDisallowed.Error() + ": " + re.URL
+}
+
+// Is reports whether target is ErrDisallowed
+func (re *RobotsError) Is(target error) bool {
+	return target == ErrDisallowed
+}
+
+// WithRobots enables robots.txt compliance. The robots.txt of the configured host is fetched once a day,
+// disallowed paths are refused with a *RobotsError and a Crawl-delay slows down the rate limiter.
+// If the limiter is a Scheduler, the Crawl-delay is waited for within the turn of the request, so requests keep their priority order.
+// The rules of the group matching userAgent are used, otherwise the rules for all user agents
+func WithRobots(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.robots = &robots{
+			userAgent: strings.ToLower(userAgent),
+			ttl:       24 * time.Hour,
+		}
+	}
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robots struct {
+	mu        sync.Mutex
+	userAgent string
+	ttl       time.Duration
+	fetchedAt time.Time
+	group     *robotsGroup
+
+	// limiterMu guards limiter separately, waiting for the crawl delay must not block the robots.txt fetch
+	limiterMu sync.Mutex
+	limiter   *rate.Limiter
+}
+
+// allowed fetches robots.txt if necessary and checks the path of rawURL
+func (c *Client) allowed(ctx context.Context, rawURL string) error {
+	if c.robots == nil {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+
+	if err != nil {
+		return err
+	}
+
+	base, err := url.Parse(c.baseURL)
+
+	// robots.txt only covers its own host
+	if err != nil || u.Host != base.Host {
+		return nil
+	}
+
+	group, err := c.robotsGroup(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if !group.allows(strings.TrimPrefix(u.RequestURI(), strings.TrimRight(base.Path, "/"))) {
+		return &RobotsError{URL: rawURL}
+	}
+
+	return nil
+}
+
+func (c *Client) robotsGroup(ctx context.Context) (*robotsGroup, error) {
+	r := c.robots
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.group != nil && time.Since(r.fetchedAt) < r.ttl {
+		return r.group, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/robots.txt", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// robots.txt is not a fetch of its own, it keeps the profile
+	c.applyProfile(contextKeepingProfile(ctx), req)
+
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		r.group = parseRobots(resp.Body, r.userAgent)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		// no robots.txt allows everything
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		r.group = &robotsGroup{}
+	default:
+		return nil, newStatusError(req.URL.String(), resp.StatusCode)
+	}
+
+	r.fetchedAt = time.Now()
+
+	var limiter *rate.Limiter
+
+	if r.group.crawlDelay > 0 {
+		limiter = rate.NewLimiter(rate.Every(r.group.crawlDelay), 1)
+	}
+
+	r.limiterMu.Lock()
+	r.limiter = limiter
+	r.limiterMu.Unlock()
+
+	return r.group, nil
+}
+
+// waitCrawlDelay waits for the Crawl-delay of robots.txt
+func (r *robots) waitCrawlDelay(ctx context.Context) error {
+	r.limiterMu.Lock()
+	limiter := r.limiter
+	r.limiterMu.Unlock()
+
+	if limiter == nil {
+		return nil
+	}
+
+	return limiter.Wait(ctx)
+}
+
+// parseRobots returns the group for userAgent or the group for all user agents
+func parseRobots(body io.Reader, userAgent string) *robotsGroup {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(body)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		splits := strings.SplitN(line, ":", 2)
+
+		if len(splits) != 2 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(splits[0]))
+		value := strings.TrimSpace(splits[1])
+
+		if key == "user-agent" {
+			// consecutive user-agent lines share one group
+			if !lastWasAgent {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+			}
+
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+
+			continue
+		}
+
+		lastWasAgent = false
+
+		if current == nil {
+			continue
+		}
+
+		switch key {
+		case "allow", "disallow":
+			// an empty disallow allows everything
+			if value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	var wildcard *robotsGroup
+
+	for _, group := range groups {
+		for _, agent := range group.agents {
+			if agent == "*" && wildcard == nil {
+				wildcard = group
+			}
+
+			if agent != "*" && userAgent != "" && strings.Contains(userAgent, agent) {
+				return group
+			}
+		}
+	}
+
+	if wildcard != nil {
+		return wildcard
+	}
+
+	return &robotsGroup{}
+}
+
+// allows applies the longest matching rule, allow wins a tie
+func (rg *robotsGroup) allows(path string) bool {
+	allowed := true
+	longest := -1
+
+	for _, rule := range rg.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed = rule.allow
+			longest = len(rule.pattern)
+		}
+	}
+
+	return allowed
+}
+
+// matchRobotsPattern matches path against a robots.txt pattern supporting * and a trailing $
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+
+	rest := path[len(parts[0]):]
+
+	for i, part := range parts[1:] {
+		// the last part has to match the end of the path if the pattern is anchored
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+
+		index := strings.Index(rest, part)
+
+		if index < 0 {
+			return false
+		}
+
+		rest = rest[index+len(part):]
+	}
+
+	return !anchored || rest == ""
+}
@@ -0,0 +1,176 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const testRobots = `# robots.txt
+User-agent: *
+Disallow: /s-anzeige/private
+Disallow: /*.json$
+Allow: /s-anzeige/private/public
+
+User-agent: Googlebot
+User-agent: goebaykleinanzeigen
+Disallow: /m-
+Crawl-delay: 0.1
+`
+
+func Test_parseRobots(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		allowed   bool
+		delay     time.Duration
+	}{
+		{name: "allowed", userAgent: "", path: "/s-anzeige/1", allowed: true},
+		{name: "disallowed", userAgent: "", path: "/s-anzeige/private/1", allowed: false},
+		{name: "longer-allow-wins", userAgent: "", path: "/s-anzeige/private/public/1", allowed: true},
+		{name: "anchored-wildcard", userAgent: "", path: "/s-ort-empfehlungen.json", allowed: false},
+		{name: "anchored-wildcard-query", userAgent: "", path: "/s-ort-empfehlungen.json?query=Berlin", allowed: true},
+		{name: "agent-group", userAgent: "goebaykleinanzeigen/1.0", path: "/s-anzeige/private/1", allowed: true, delay: 100 * time.Millisecond},
+		{name: "agent-group-disallowed", userAgent: "goebaykleinanzeigen/1.0", path: "/m-meine-anzeigen.html", allowed: false, delay: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := parseRobots(strings.NewReader(testRobots), tt.userAgent)
+
+			if got := group.allows(tt.path); got != tt.allowed {
+				t.Errorf("allows(%v) = %v, want %v", tt.path, got, tt.allowed)
+			}
+
+			if group.crawlDelay != tt.delay {
+				t.Errorf("crawlDelay = %v, want %v", group.crawlDelay, tt.delay)
+			}
+		})
+	}
+}
+
+func Test_ClientRobots(t *testing.T) {
+	robotsRequests := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsRequests++
+			_, _ = w.Write([]byte(testRobots))
+			return
+		}
+
+		http.ServeFile(w, r, "testdata/aditem/generic-ad.html")
+	}))
+	defer srv.Close()
+
+	ar := NewClient(WithoutRateLimit(), WithBaseURL(srv.URL), WithRobots("goebaykleinanzeigen")).AdRepo()
+
+	_, err := ar.Fetch(context.Background(), "/m-meine-anzeigen.html")
+
+	var re *RobotsError
+
+	if !errors.As(err, &re) || !errors.Is(err, ErrDisallowed) {
+		t.Errorf("expected RobotsError, got %v", err)
+	}
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if _, err := ar.Fetch(context.Background(), "/s-anzeige/1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the crawl delay of 100ms is applied between the requests
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("3 requests took %v, crawl delay was not honored", elapsed)
+	}
+
+	if robotsRequests != 1 {
+		t.Errorf("robots.txt was requested %d times, want 1", robotsRequests)
+	}
+}
+
+func Test_CrawlDelayKeepsPriority(t *testing.T) {
+	s := NewScheduler(nil, WithAging(0))
+	client := NewClient(WithLimiter(s), WithRobots("goebaykleinanzeigen"))
+	client.robots.limiter = rate.NewLimiter(rate.Every(50*time.Millisecond), 1)
+
+	// takes the burst, the following requests wait for the crawl delay
+	if err := client.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	order := []string{}
+
+	start := func(name string, priority Priority, queued int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := client.wait(contextWithPriority(context.Background(), priority)); err != nil {
+				t.Error(err)
+				return
+			}
+
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}()
+
+		waitQueued(t, s, queued)
+	}
+
+	start("bulk-1", PriorityBulk, 0)
+	start("bulk-2", PriorityBulk, 1)
+	start("bulk-3", PriorityBulk, 2)
+	start("interactive", PriorityInteractive, 3)
+
+	wg.Wait()
+
+	want := []string{"bulk-1", "interactive", "bulk-2", "bulk-3"}
+
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func Test_CrawlDelaySharedScheduler(t *testing.T) {
+	s := NewScheduler(nil, WithAging(0))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		client := NewClient(WithLimiter(s), WithRobots("goebaykleinanzeigen"))
+		client.robots.limiter = rate.NewLimiter(rate.Every(time.Hour), 1)
+
+		// the crawl delay of one client must not add up with the other one
+		if err := client.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if s.limiter != nil {
+		t.Errorf("the limiter of the Scheduler was replaced")
+	}
+
+	plain := NewClient(WithLimiter(s))
+
+	// a client without robots.txt is not slowed down by the crawl delay of the others
+	for i := 0; i < 3; i++ {
+		if err := plain.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
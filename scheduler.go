@@ -0,0 +1,217 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Priority decides the order in which a Scheduler hands out request slots
+type Priority int
+
+const (
+	// PriorityInteractive is meant for lookups a human is waiting for
+	PriorityInteractive Priority = iota
+	// PriorityNormal is used if no priority is set
+	PriorityNormal
+	// PriorityBulk is meant for background crawls
+	PriorityBulk
+)
+
+type priorityKey struct{}
+
+// WithPriority sets the priority of a single Fetch call, it only has an effect if the Client uses a Scheduler
+func WithPriority(priority Priority) FetchOption {
+	return func(fo *fetchOptions) {
+		fo.priority = &priority
+	}
+}
+
+func contextWithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+type turnWaitKey struct{}
+
+// contextWithTurnWait adds a wait which a Scheduler performs within the turn of the request after the underlying Limiter
+func contextWithTurnWait(ctx context.Context, wait func(context.Context) error) context.Context {
+	return context.WithValue(ctx, turnWaitKey{}, wait)
+}
+
+func priorityFromContext(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+
+	return PriorityNormal
+}
+
+// Scheduler is a Limiter which queues requests by priority in front of another Limiter.
+// Only one request at a time waits for the underlying Limiter, every following slot goes to the
+// queued request with the highest priority. To prevent starvation a queued request is promoted
+// by one priority class for every aging interval it waited.
+// A Scheduler is safe for concurrent use by multiple goroutines
+type Scheduler struct {
+	mu      sync.Mutex
+	limiter Limiter
+	aging   time.Duration
+	busy    bool
+	queue   []*schedulerWaiter
+	now     func() time.Time
+}
+
+type schedulerWaiter struct {
+	priority Priority
+	enqueued time.Time
+	ready    chan struct{}
+}
+
+// SchedulerOption configures a Scheduler
+type SchedulerOption func(*Scheduler)
+
+// WithAging sets the interval after which a queued request is promoted by one priority class, default is 30 seconds.
+// An interval of 0 disables the starvation protection
+func WithAging(aging time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.aging = aging
+	}
+}
+
+// NewScheduler creates a new Scheduler in front of limiter
+func NewScheduler(limiter Limiter, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		limiter: limiter,
+		aging:   30 * time.Second,
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Wait blocks until it is the turn of the caller and the underlying Limiter allows the request.
+// The priority is taken from the context, see WithPriority
+func (s *Scheduler) Wait(ctx context.Context) error {
+	s.mu.Lock()
+
+	if !s.busy {
+		s.busy = true
+		s.mu.Unlock()
+
+		return s.turn(ctx)
+	}
+
+	w := &schedulerWaiter{
+		priority: priorityFromContext(ctx),
+		enqueued: s.now(),
+		ready:    make(chan struct{}),
+	}
+	s.queue = append(s.queue, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.turn(ctx)
+	case <-ctx.Done():
+		s.mu.Lock()
+
+		if s.remove(w) {
+			s.mu.Unlock()
+			return ctx.Err()
+		}
+
+		s.mu.Unlock()
+
+		// the turn was handed to us in the meantime, pass it on
+		s.release()
+
+		return ctx.Err()
+	}
+}
+
+// Observe forwards the observation if the underlying Limiter is an ObservingLimiter
+func (s *Scheduler) Observe(obs Observation) {
+	if ol, ok := s.limiter.(ObservingLimiter); ok {
+		ol.Observe(obs)
+	}
+}
+
+// Queued returns the number of requests waiting for their turn
+func (s *Scheduler) Queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.queue)
+}
+
+func (s *Scheduler) turn(ctx context.Context) error {
+	defer s.release()
+
+	if s.limiter != nil {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if wait, ok := ctx.Value(turnWaitKey{}).(func(context.Context) error); ok {
+		return wait(ctx)
+	}
+
+	return nil
+}
+
+// release hands the turn to the next waiter
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.next()
+
+	if next == nil {
+		s.busy = false
+		return
+	}
+
+	s.remove(next)
+	close(next.ready)
+}
+
+// next returns the waiter with the highest effective priority, the oldest one wins a tie
+func (s *Scheduler) next() *schedulerWaiter {
+	var best *schedulerWaiter
+	var bestPriority Priority
+	now := s.now()
+
+	for _, w := range s.queue {
+		priority := w.priority
+
+		if s.aging > 0 {
+			priority -= Priority(now.Sub(w.enqueued) / s.aging)
+		}
+
+		if priority < PriorityInteractive {
+			priority = PriorityInteractive
+		}
+
+		if best == nil || priority < bestPriority || (priority == bestPriority && w.enqueued.Before(best.enqueued)) {
+			best = w
+			bestPriority = priority
+		}
+	}
+
+	return best
+}
+
+func (s *Scheduler) remove(w *schedulerWaiter) bool {
+	for i, queued := range s.queue {
+		if queued == w {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
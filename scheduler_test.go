@@ -0,0 +1,180 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenLimiter hands out a token whenever the test sends one
+type tokenLimiter chan struct{}
+
+func (tl tokenLimiter) Wait(ctx context.Context) error {
+	select {
+	case <-tl:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func waitQueued(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+
+	for i := 0; i < 1000; i++ {
+		s.mu.Lock()
+		busy := s.busy
+		s.mu.Unlock()
+
+		if busy && s.Queued() == n {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("Queued() = %d, want %d", s.Queued(), n)
+}
+
+func Test_SchedulerPriority(t *testing.T) {
+	tokens := make(tokenLimiter)
+	s := NewScheduler(tokens, WithAging(0))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	order := []string{}
+
+	start := func(name string, priority Priority, queued int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := s.Wait(contextWithPriority(context.Background(), priority)); err != nil {
+				t.Error(err)
+				return
+			}
+
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}()
+
+		waitQueued(t, s, queued)
+	}
+
+	// the first request waits for the limiter, all others are queued
+	start("bulk-1", PriorityBulk, 0)
+	start("bulk-2", PriorityBulk, 1)
+	start("bulk-3", PriorityBulk, 2)
+	start("normal", PriorityNormal, 3)
+	start("interactive", PriorityInteractive, 4)
+
+	for i := 0; i < 5; i++ {
+		tokens <- struct{}{}
+
+		// wait until the request is recorded before handing out the next token
+		for {
+			mu.Lock()
+			recorded := len(order)
+			mu.Unlock()
+
+			if recorded > i {
+				break
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	wg.Wait()
+
+	want := []string{"bulk-1", "interactive", "normal", "bulk-2", "bulk-3"}
+
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func Test_SchedulerAging(t *testing.T) {
+	now := time.Date(2021, 3, 22, 12, 0, 0, 0, time.UTC)
+	s := NewScheduler(nil, WithAging(time.Minute))
+	s.now = func() time.Time { return now }
+
+	old := &schedulerWaiter{priority: PriorityBulk, enqueued: now.Add(-2 * time.Minute)}
+	fresh := &schedulerWaiter{priority: PriorityInteractive, enqueued: now}
+	s.queue = []*schedulerWaiter{fresh, old}
+
+	if got := s.next(); got != old {
+		t.Errorf("starving bulk request was not promoted")
+	}
+}
+
+func Test_SchedulerCancel(t *testing.T) {
+	tokens := make(tokenLimiter)
+	s := NewScheduler(tokens)
+
+	done := make(chan error)
+
+	go func() {
+		done <- s.Wait(context.Background())
+	}()
+
+	waitQueued(t, s, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := make(chan error)
+
+	go func() {
+		queued <- s.Wait(ctx)
+	}()
+
+	waitQueued(t, s, 1)
+	cancel()
+
+	if err := <-queued; err != context.Canceled {
+		t.Errorf("Wait() = %v, want %v", err, context.Canceled)
+	}
+
+	if s.Queued() != 0 {
+		t.Errorf("cancelled request is still queued")
+	}
+
+	tokens <- struct{}{}
+
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+type priorityLimiter struct {
+	got Priority
+}
+
+func (pl *priorityLimiter) Wait(ctx context.Context) error {
+	pl.got = priorityFromContext(ctx)
+	return nil
+}
+
+func Test_FetchWithPriority(t *testing.T) {
+	srv := newFixtureServer(t)
+	limiter := &priorityLimiter{}
+	ar := NewClient(WithLimiter(limiter), WithBaseURL(srv.URL)).AdRepo()
+
+	if _, err := ar.Fetch(context.Background(), "/s-anzeige/1", WithPriority(PriorityInteractive)); err != nil {
+		t.Fatal(err)
+	}
+
+	if limiter.got != PriorityInteractive {
+		t.Errorf("limiter saw priority %v, want %v", limiter.got, PriorityInteractive)
+	}
+
+	if _, err := ar.Fetch(context.Background(), "/s-anzeige/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if limiter.got != PriorityNormal {
+		t.Errorf("limiter saw priority %v, want %v", limiter.got, PriorityNormal)
+	}
+}
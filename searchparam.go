@@ -17,7 +17,7 @@ type Provider string
 type OfferType string
 
 // LocationID represents a specifc location
-// retrived from https://www.ebay-kleinanzeigen.de/s-ort-empfehlungen.json?query=Berlin for example
+// retrived from https://www.kleinanzeigen.de/s-ort-empfehlungen.json?query=Berlin for example
 type LocationID string
 
 // Radius represents the distance from the center of the location
@@ -27,7 +27,10 @@ type Radius string
 type SpecificParameter map[ParamName]string
 
 const (
-	baseURL string = "https://www.ebay-kleinanzeigen.de"
+	// DefaultBaseURL is the current domain of the marketplace
+	DefaultBaseURL string = "https://www.kleinanzeigen.de"
+	// LegacyBaseURL is the domain used before the marketplace moved to DefaultBaseURL
+	LegacyBaseURL string = "https://www.ebay-kleinanzeigen.de"
 )
 
 const (
@@ -189,7 +192,7 @@ func (sp *SearchParam) fmtSpecificParameter() string {
 	return sb.String()
 }
 
-func (sp *SearchParam) toURL() string {
+func (sp *SearchParam) toURL(base string) string {
 	sb := strings.Builder{}
 
 	if sp.Page < 1 {
@@ -207,5 +210,5 @@ func (sp *SearchParam) toURL() string {
 
 	params := strings.Trim(sb.String(), "/")
 
-	return baseURL + "/" + params
+	return base + "/" + params
 }
@@ -286,21 +286,21 @@ func Test_toURL(t *testing.T) {
 		{
 			name: "empty-params",
 			sp:   &SearchParam{},
-			want: baseURL + "/seite:1",
+			want: DefaultBaseURL + "/seite:1",
 		},
 		{
 			name: "param-car",
 			sp: &SearchParam{
 				Category: Cars,
 			},
-			want: baseURL + "/seite:1/c" + string(Cars),
+			want: DefaultBaseURL + "/seite:1/c" + string(Cars),
 		},
 		{
 			name: "param-page",
 			sp: &SearchParam{
 				Page: 23,
 			},
-			want: baseURL + "/seite:23",
+			want: DefaultBaseURL + "/seite:23",
 		},
 		{
 			name: "param-price",
@@ -308,7 +308,7 @@ func Test_toURL(t *testing.T) {
 				PriceFrom: 1000,
 				PriceTo:   5000,
 			},
-			want: baseURL + "/preis:1000:5000/seite:1",
+			want: DefaultBaseURL + "/preis:1000:5000/seite:1",
 		},
 		{
 			name: "param-basic",
@@ -321,13 +321,13 @@ func Test_toURL(t *testing.T) {
 					CarManufacturer: "bmw",
 				},
 			},
-			want: baseURL + "/anzeige:angebote/seite:1/c216l3331r10+autos.marke_s:bmw",
+			want: DefaultBaseURL + "/anzeige:angebote/seite:1/c216l3331r10+autos.marke_s:bmw",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.sp.toURL(); got != tt.want {
+			if got := tt.sp.toURL(DefaultBaseURL); got != tt.want {
 				t.Errorf("SearchParam.toURL() = %v, want %v", got, tt.want)
 			}
 		})
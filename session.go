@@ -0,0 +1,353 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ErrNoConsentForm is returned by DefaultConsent if the consent page has no form to accept
+var ErrNoConsentForm = errors.New("no consent form found")
+
+// ConsentFunc performs the consent step after a consent page was served.
+// The client uses the cookie jar of the Session and sends its requests through the rate limiter, the circuit breaker
+// and the hooks of the Client with the current header profile. pageURL and body belong to the consent page
+type ConsentFunc func(ctx context.Context, client *http.Client, pageURL string, body []byte) error
+
+// Session keeps the cookies of a Client, so consecutive requests look like a single visitor.
+// It performs the consent step once if a consent page is served, can persist its cookies to disk
+// and can be rotated to start over as a new visitor.
+// A Session is safe for concurrent use by multiple goroutines
+type Session struct {
+	mu        sync.Mutex
+	path      string
+	jar       http.CookieJar
+	cookies   map[string]*storedCookie
+	consent   ConsentFunc
+	consented bool
+}
+
+type storedCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// SessionOption configures a Session
+type SessionOption func(*Session)
+
+// WithConsentFunc replaces DefaultConsent
+func WithConsentFunc(fn ConsentFunc) SessionOption {
+	return func(s *Session) {
+		s.consent = fn
+	}
+}
+
+// WithSession sets the Session of the Client. A caller supplied http.Client is copied and not modified
+func WithSession(session *Session) ClientOption {
+	return func(c *Client) {
+		c.session = session
+	}
+}
+
+// NewSession creates a new Session, cookies are loaded from and saved to path if it is not empty
+func NewSession(path string, opts ...SessionOption) (*Session, error) {
+	s := &Session{
+		path:    path,
+		consent: DefaultConsent,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.reset()
+
+	if path == "" {
+		return s, nil
+	}
+
+	raw, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	stored := []*storedCookie{}
+
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, err
+	}
+
+	for _, sc := range stored {
+		u, err := url.Parse(sc.URL)
+
+		if err != nil || sc.Cookie == nil {
+			continue
+		}
+
+		s.SetCookies(u, []*http.Cookie{sc.Cookie})
+	}
+
+	return s, nil
+}
+
+// SetCookies implements http.CookieJar
+func (s *Session) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jar.SetCookies(u, cookies)
+
+	for _, cookie := range cookies {
+		key := u.Host + ";" + cookie.Path + ";" + cookie.Name
+		origin := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"}
+
+		if cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(time.Now())) {
+			delete(s.cookies, key)
+			continue
+		}
+
+		s.cookies[key] = &storedCookie{URL: origin.String(), Cookie: cookie}
+	}
+}
+
+// Cookies implements http.CookieJar
+func (s *Session) Cookies(u *url.URL) []*http.Cookie {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.jar.Cookies(u)
+}
+
+// Save writes the cookies to the path of the Session
+func (s *Session) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path == "" {
+		return nil
+	}
+
+	stored := make([]*storedCookie, 0, len(s.cookies))
+
+	for _, sc := range s.cookies {
+		stored = append(stored, sc)
+	}
+
+	raw, err := json.MarshalIndent(stored, "", "	")
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path, raw, 0o600)
+}
+
+// Rotate drops all cookies and the consent, the next request starts a new session
+func (s *Session) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset()
+
+	if s.path == "" {
+		return nil
+	}
+
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Session) reset() {
+	// cookiejar.New only fails for invalid options
+	s.jar, _ = cookiejar.New(nil)
+	s.cookies = map[string]*storedCookie{}
+	s.consented = false
+}
+
+// giveConsent performs the consent step once per session.
+// It reports false if the consent was already given, so a consent page is not answered in a loop.
+// Concurrent callers don't send the consent twice, the consent is only kept if it succeeded
+func (s *Session) giveConsent(ctx context.Context, client *http.Client, pageURL string, body []byte) (bool, error) {
+	s.mu.Lock()
+
+	if s.consented {
+		s.mu.Unlock()
+		return false, nil
+	}
+
+	s.consented = true
+	s.mu.Unlock()
+
+	if err := s.consent(ctx, client, pageURL, body); err != nil {
+		// a failed consent is tried again on the next consent page
+		s.mu.Lock()
+		s.consented = false
+		s.mu.Unlock()
+
+		return false, err
+	}
+
+	return true, s.Save()
+}
+
+// consentClient returns a copy of the http.Client which sends its requests like regular fetches:
+// they pass the circuit breaker and the rate limiter, carry the header profile and are reported to the hooks
+func (c *Client) consentClient() *http.Client {
+	next := c.httpClient.Transport
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	httpClient := *c.httpClient
+	httpClient.Transport = &throttledTransport{client: c, next: next}
+
+	return &httpClient
+}
+
+type throttledTransport struct {
+	client *Client
+	next   http.RoundTripper
+}
+
+func (tt *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := tt.client
+	ctx := req.Context()
+
+	if c.breaker != nil {
+		if err := c.breaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	waitStart := time.Now()
+
+	if err := c.wait(ctx); err != nil {
+		c.recordConsent(err)
+		return nil, err
+	}
+
+	// a RoundTripper must not modify the request
+	req = req.Clone(ctx)
+	profile := c.applyProfile(ctx, req)
+	url := req.URL.String()
+
+	c.beforeRequest(ctx, req, &RequestInfo{
+		Kind:        ConsentRequest,
+		URL:         url,
+		Attempt:     1,
+		LimiterWait: time.Since(waitStart),
+		Profile:     profile,
+	})
+
+	start := time.Now()
+	resp, err := tt.next.RoundTrip(req)
+
+	info := &ResponseInfo{
+		Kind:    ConsentRequest,
+		URL:     url,
+		Attempt: 1,
+		Latency: time.Since(start),
+		Err:     err,
+		Profile: profile,
+	}
+
+	if err != nil {
+		c.afterResponse(ctx, info)
+		c.observe(Observation{Kind: ConsentRequest, Latency: info.Latency, Err: err})
+		c.recordConsent(err)
+
+		return nil, err
+	}
+
+	info.StatusCode = resp.StatusCode
+	c.afterResponse(ctx, info)
+	c.observe(Observation{Kind: ConsentRequest, StatusCode: resp.StatusCode, Latency: info.Latency})
+
+	if resp.StatusCode >= 400 {
+		c.recordConsent(newStatusError(url, resp.StatusCode))
+	} else {
+		c.recordConsent(nil)
+	}
+
+	return resp, nil
+}
+
+func (c *Client) recordConsent(err error) {
+	if c.breaker != nil {
+		c.breaker.record(breakerOutcomeOf(err))
+	}
+}
+
+// DefaultConsent submits the form containing the accept button of the consent page
+func DefaultConsent(ctx context.Context, client *http.Client, pageURL string, body []byte) error {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+
+	if err != nil {
+		return err
+	}
+
+	form := doc.Find("[data-gdpr-consent-accept], #gdpr-banner-accept").First().Closest("form")
+
+	if form.Length() == 0 {
+		return ErrNoConsentForm
+	}
+
+	base, err := url.Parse(pageURL)
+
+	if err != nil {
+		return err
+	}
+
+	action, err := base.Parse(form.AttrOr("action", ""))
+
+	if err != nil {
+		return err
+	}
+
+	values := url.Values{}
+
+	form.Find("input[name]").Each(func(_ int, s *goquery.Selection) {
+		values.Add(s.AttrOr("name", ""), s.AttrOr("value", ""))
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "POST", action.String(), strings.NewReader(values.Encode()))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newStatusError(action.String(), resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package goebaykleinanzeigen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func newConsentServer(t *testing.T, consents *int32) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gdpr/consent" {
+			atomic.AddInt32(consents, 1)
+
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("consent") != "all" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			http.SetCookie(w, &http.Cookie{Name: "consent", Value: "all", Path: "/", MaxAge: 3600})
+			return
+		}
+
+		if _, err := r.Cookie("consent"); err != nil {
+			http.ServeFile(w, r, "testdata/interstitial/consent.html")
+			return
+		}
+
+		http.ServeFile(w, r, "testdata/aditem/generic-ad.html")
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func Test_SessionConsent(t *testing.T) {
+	var consents int32
+	srv := newConsentServer(t, &consents)
+	path := filepath.Join(t.TempDir(), "cookies.json")
+
+	session, err := NewSession(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := &http.Client{}
+	ar := NewClient(WithHTTPClient(httpClient), WithoutRateLimit(), WithBaseURL(srv.URL), WithSession(session)).AdRepo()
+
+	for i := 0; i < 2; i++ {
+		item, err := ar.Fetch(context.Background(), "/s-anzeige/1707662827")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.ID != "1707662827" {
+			t.Errorf("AdItem.ID = %v, want %v", item.ID, "1707662827")
+		}
+	}
+
+	if consents != 1 {
+		t.Errorf("consents = %v, want %v", consents, 1)
+	}
+
+	if httpClient.Jar != nil {
+		t.Error("caller supplied http.Client was modified")
+	}
+
+	// a new process picks up the persisted cookies
+	restored, err := NewSession(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ar = NewClient(WithoutRateLimit(), WithBaseURL(srv.URL), WithSession(restored)).AdRepo()
+
+	if _, err := ar.Fetch(context.Background(), "/s-anzeige/1707662827"); err != nil {
+		t.Fatal(err)
+	}
+
+	if consents != 1 {
+		t.Errorf("consents after restore = %v, want %v", consents, 1)
+	}
+
+	if err := restored.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ar.Fetch(context.Background(), "/s-anzeige/1707662827"); err != nil {
+		t.Fatal(err)
+	}
+
+	if consents != 2 {
+		t.Errorf("consents after rotate = %v, want %v", consents, 2)
+	}
+}
+
+func Test_SessionConsentOnce(t *testing.T) {
+	var consents int32
+
+	// the server ignores the consent and keeps serving the consent page
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gdpr/consent" {
+			atomic.AddInt32(&consents, 1)
+			return
+		}
+
+		http.ServeFile(w, r, "testdata/interstitial/consent.html")
+	}))
+	defer srv.Close()
+
+	session, err := NewSession("")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ar := NewClient(WithoutRateLimit(), WithBaseURL(srv.URL), WithSession(session)).AdRepo()
+
+	for i := 0; i < 2; i++ {
+		_, err := ar.Fetch(context.Background(), "/s-anzeige/1707662827")
+
+		if !errors.Is(err, ErrInterstitial) {
+			t.Errorf("expected ErrInterstitial, got %v", err)
+		}
+	}
+
+	if consents != 1 {
+		t.Errorf("consents = %v, want %v", consents, 1)
+	}
+}
+
+func Test_SessionConsentRetriedAfterFailure(t *testing.T) {
+	var consents int32
+	srv := newConsentServer(t, &consents)
+
+	failing := true
+
+	session, err := NewSession("", WithConsentFunc(func(ctx context.Context, client *http.Client, pageURL string, body []byte) error {
+		if failing {
+			failing = false
+			return ErrNoConsentForm
+		}
+
+		return DefaultConsent(ctx, client, pageURL, body)
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ar := NewClient(WithoutRateLimit(), WithBaseURL(srv.URL), WithSession(session)).AdRepo()
+
+	if _, err := ar.Fetch(context.Background(), "/s-anzeige/1707662827"); !errors.Is(err, ErrNoConsentForm) {
+		t.Errorf("expected ErrNoConsentForm, got %v", err)
+	}
+
+	if _, err := ar.Fetch(context.Background(), "/s-anzeige/1707662827"); err != nil {
+		t.Fatal(err)
+	}
+
+	if consents != 1 {
+		t.Errorf("consents = %v, want %v", consents, 1)
+	}
+}
+
+func Test_SessionConsentThrottled(t *testing.T) {
+	var consentAgent string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gdpr/consent" {
+			consentAgent = r.Header.Get("User-Agent")
+			http.SetCookie(w, &http.Cookie{Name: "consent", Value: "all", Path: "/"})
+			return
+		}
+
+		if _, err := r.Cookie("consent"); err != nil {
+			http.ServeFile(w, r, "testdata/interstitial/consent.html")
+			return
+		}
+
+		http.ServeFile(w, r, "testdata/aditem/generic-ad.html")
+	}))
+	defer srv.Close()
+
+	session, err := NewSession("")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []RequestKind
+
+	limiter := &countingLimiter{}
+	profiles := []*HeaderProfile{{Name: "a", Header: http.Header{"User-Agent": {"agent-a"}}}}
+
+	ar := NewClient(
+		WithLimiter(limiter),
+		WithBaseURL(srv.URL),
+		WithSession(session),
+		WithHeaderRotator(NewHeaderRotator(profiles)),
+		WithHooks(Hooks{
+			BeforeRequest: func(_ context.Context, _ *http.Request, info *RequestInfo) {
+				kinds = append(kinds, info.Kind)
+			},
+		}),
+	).AdRepo()
+
+	if _, err := ar.Fetch(context.Background(), "/s-anzeige/1707662827"); err != nil {
+		t.Fatal(err)
+	}
+
+	if limiter.count != 3 {
+		t.Errorf("limiter waits = %v, want %v", limiter.count, 3)
+	}
+
+	if consentAgent != "agent-a" {
+		t.Errorf("consent User-Agent = %v, want %v", consentAgent, "agent-a")
+	}
+
+	want := []RequestKind{AdRequest, ConsentRequest, AdRequest}
+
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("request kinds = %v, want %v", kinds, want)
+	}
+}